@@ -3,8 +3,10 @@ package astilectron
 import (
 	"fmt"
 	"net"
+	"net/http"
 	"os/exec"
 	"runtime"
+	"sync"
 	"time"
 
 	"github.com/asticode/go-astikit"
@@ -43,22 +45,30 @@ const (
 
 // Astilectron represents an object capable of interacting with Astilectron
 type Astilectron struct {
-	dispatcher   *dispatcher
-	displayPool  *displayPool
-	dock         *Dock
-	executer     Executer
-	identifier   *identifier
-	l            astikit.SeverityLogger
-	listener     net.Listener
-	options      Options
-	paths        *Paths
-	provisioner  Provisioner
-	reader       *reader
-	stderrWriter *astikit.WriterAdapter
-	stdoutWriter *astikit.WriterAdapter
-	supported    *Supported
-	worker       *astikit.Worker
-	writer       *writer
+	beforeQuitHooks           closeHooks
+	certificateErrorHandler   func(e CertificateError) bool
+	certificateErrorListening bool
+	dispatcher                *dispatcher
+	displayPool               *displayPool
+	dock                      *Dock
+	executer                  Executer
+	fsHandled                 map[string]*fsHandling // Indexed by session partition, "" being the default session
+	fsRouter                  *fsRouter
+	identifier                *identifier
+	l                         astikit.SeverityLogger
+	listener                  net.Listener
+	m                         sync.Mutex // Locks certificateErrorHandler, certificateErrorListening, fsHandled and sessions
+	options                   Options
+	paths                     *Paths
+	protocolHandlers          *protocolHandlers
+	provisioner               Provisioner
+	reader                    *reader
+	stderrWriter              *astikit.WriterAdapter
+	stdoutWriter              *astikit.WriterAdapter
+	sessions                  map[string]*Session // Indexed by partition
+	supported                 *Supported
+	worker                    *astikit.Worker
+	writer                    *writer
 }
 
 // Options represents Astilectron options
@@ -71,6 +81,7 @@ type Options struct {
 	BaseDirectoryPath  string
 	DataDirectoryPath  string
 	ElectronSwitches   []string
+	ProtocolSchemes    []ProtocolScheme // Custom schemes registered as privileged before Electron's app is ready
 	SingleInstance     bool
 	SkipSetup          bool // If true, the user must handle provisioning and executing astilectron.
 	TCPPort            *int // The port to listen on.
@@ -103,13 +114,19 @@ func New(l astikit.StdLogger, o Options) (a *Astilectron, err error) {
 		dispatcher:  newDispatcher(),
 		displayPool: newDisplayPool(),
 		executer:    DefaultExecuter,
+		fsHandled:   make(map[string]*fsHandling),
+		fsRouter:    newFSRouter(),
 		identifier:  newIdentifier(),
 		l:           astikit.AdaptStdLogger(l),
 		options:     o,
 		provisioner: newDefaultProvisioner(l),
+		sessions:    make(map[string]*Session),
 		worker:      astikit.NewWorker(astikit.WorkerOptions{Logger: l}),
 	}
 
+	// Init protocol handlers
+	a.protocolHandlers = newProtocolHandlers(a.l, a, targetIDApp)
+
 	// Set paths
 	if a.paths, err = newPaths(runtime.GOOS, runtime.GOARCH, o); err != nil {
 		err = fmt.Errorf("creating new paths failed: %w", err)
@@ -288,7 +305,13 @@ func (a *Astilectron) execute() (err error) {
 	} else {
 		singleInstance = "false"
 	}
-	var cmd = exec.CommandContext(a.worker.Context(), a.paths.AppExecutable(), append([]string{a.paths.AstilectronApplication(), a.listener.Addr().String(), singleInstance}, a.options.ElectronSwitches...)...)
+	var args = append([]string{a.paths.AstilectronApplication(), a.listener.Addr().String(), singleInstance}, a.options.ElectronSwitches...)
+	var s string
+	if s, err = protocolSchemesSwitchValue(append([]ProtocolScheme{fsProtocolScheme()}, a.options.ProtocolSchemes...)); err != nil {
+		return fmt.Errorf("creating protocol schemes switch failed: %w", err)
+	}
+	args = append(args, s)
+	var cmd = exec.CommandContext(a.worker.Context(), a.paths.AppExecutable(), args...)
 	a.stderrWriter = astikit.NewWriterAdapter(astikit.WriterAdapterOptions{
 		Callback: func(i []byte) { a.l.Debugf("Stderr says: %s", i) },
 		Split:    []byte("\n"),
@@ -405,6 +428,18 @@ func (a *Astilectron) Quit() error {
 	return a.writer.write(Event{Name: EventNameAppCmdQuit})
 }
 
+// HandleProtocol serves a custom scheme in the default session with an http.Handler
+// Requests are forwarded with their method, headers and upload body, and responses are streamed back in chunks.
+// Use Options.ProtocolSchemes to register the scheme as privileged.
+func (a *Astilectron) HandleProtocol(scheme string, h http.Handler) error {
+	return a.protocolHandlers.handle(a.worker.Context(), a.writer, scheme, h)
+}
+
+// UnhandleProtocol stops serving a custom scheme in the default session
+func (a *Astilectron) UnhandleProtocol(scheme string) error {
+	return a.protocolHandlers.unhandle(a.worker.Context(), a.writer, scheme)
+}
+
 // Paths returns the paths
 func (a *Astilectron) Paths() Paths {
 	return *a.paths
@@ -431,8 +466,79 @@ func (a *Astilectron) NewMenu(i []*MenuItemOptions) *Menu {
 }
 
 // NewWindow creates a new window
-func (a *Astilectron) NewWindow(url string, o *WindowOptions) (*Window, error) {
-	return newWindow(a.worker.Context(), a.l, a.options, a.Paths(), url, o, a.dispatcher, a.identifier, a.writer)
+// If o.FS is set, url is a path inside o.FS
+func (a *Astilectron) NewWindow(url string, o *WindowOptions) (w *Window, err error) {
+	if w, err = newWindow(a.worker.Context(), a.l, a.options, a.Paths(), url, o, a.displayPool, a.dispatcher, a.identifier, a.writer); err != nil {
+		return
+	}
+	w.newWindow = a.NewWindow
+	if err = a.handleWindowFS(w); err != nil {
+		err = fmt.Errorf("handling window fs failed: %w", err)
+		return
+	}
+	return
+}
+
+// handleWindowFS makes sure the window's fs.FS, if any, is served
+func (a *Astilectron) handleWindowFS(w *Window) (err error) {
+	// No fs
+	if w.o.FS == nil {
+		return
+	}
+
+	// Get handling
+	// The mutex is not held while waiting for Electron so that other session operations are not blocked
+	p := w.Session.Partition()
+	a.m.Lock()
+	h, ok := a.fsHandled[p]
+	if !ok {
+		h = &fsHandling{done: make(chan struct{})}
+		a.fsHandled[p] = h
+	}
+	a.m.Unlock()
+
+	// Handle protocol in the window's session
+	if !ok {
+		if p == "" {
+			h.err = a.HandleProtocol(FSScheme, a.fsRouter)
+		} else {
+			h.err = w.Session.HandleProtocol(FSScheme, a.fsRouter)
+		}
+		if h.err != nil {
+			// Let the next window try again
+			a.m.Lock()
+			delete(a.fsHandled, p)
+			a.m.Unlock()
+		}
+		close(h.done)
+	} else {
+		select {
+		case <-h.done:
+		case <-w.ctx.Done():
+			err = w.ctx.Err()
+			return
+		}
+	}
+	if h.err != nil {
+		err = fmt.Errorf("handling protocol failed: %w", h.err)
+		return
+	}
+
+	// Add fs
+	host := fsHost(w.id)
+	a.fsRouter.add(host, w.o.FS)
+	w.On(EventNameWindowEventClosed, func(e Event) (deleteListener bool) {
+		a.fsRouter.del(host)
+		return true
+	})
+	return
+}
+
+// fsHandling represents the handling of the fs scheme in a session
+// done is closed once Electron has answered, after which err can be read.
+type fsHandling struct {
+	done chan struct{}
+	err  error
 }
 
 //New BrowserView creates a new browserview
@@ -441,7 +547,37 @@ func (a *Astilectron) NewBrowserView(url string, o *WindowOptions, s *Session) (
 }
 
 func (a *Astilectron) NewSession() *Session {
-	return newSession(a.worker.Context(), a.dispatcher, a.identifier, a.writer)
+	return newSession(a.worker.Context(), a.l, a.dispatcher, a.identifier, a.writer)
+}
+
+// SessionFromPartition returns the session bound to a partition, creating it the first time
+// Partitions starting with "persist:" are persisted on disk. The session can be used in WindowOptions.Session or
+// passed to NewBrowserView.
+func (a *Astilectron) SessionFromPartition(partition string) (s *Session, err error) {
+	// Session already exists
+	a.m.Lock()
+	s, ok := a.sessions[partition]
+	a.m.Unlock()
+	if ok {
+		return
+	}
+
+	// Create session
+	s = a.NewSession()
+	if err = s.FromPartition(partition); err != nil {
+		err = fmt.Errorf("binding session to partition %s failed: %w", partition, err)
+		return
+	}
+
+	// Store session, unless another one has been stored in the meantime
+	a.m.Lock()
+	defer a.m.Unlock()
+	if v, ok := a.sessions[partition]; ok {
+		s = v
+		return
+	}
+	a.sessions[partition] = s
+	return
 }
 
 // NewWindowInDisplay creates a new window in a specific display
@@ -457,7 +593,7 @@ func (a *Astilectron) NewWindowInDisplay(d *Display, url string, o *WindowOption
 	} else {
 		o.Y = astikit.IntPtr(d.Bounds().Y)
 	}
-	return newWindow(a.worker.Context(), a.l, a.options, a.Paths(), url, o, a.dispatcher, a.identifier, a.writer)
+	return a.NewWindow(url, o)
 }
 
 // NewTray creates a new tray
@@ -0,0 +1,228 @@
+package astilectron
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Automation misc
+const (
+	automationDefaultTimeout = 5 * time.Second
+	automationEnabled        = "if (el.disabled) { return {disabled: true, found: true}; }" // Check of the actions that need the element to be enabled
+	automationPollInterval   = 100 * time.Millisecond
+)
+
+// Automation errors
+var (
+	ErrElementDisabled = errors.New("element disabled")  // The element has been found but stayed disabled until the timeout
+	ErrElementNotFound = errors.New("element not found") // No element matching the selector could be found before the timeout
+)
+
+// Automation represents an object capable of driving the web contents of a window or a browser view, which is mostly
+// useful in end-to-end tests
+// Actions on elements wait for the element to be attached to the DOM and visible, and for clicks and typing to be
+// enabled, before being executed.
+// Scripts are evaluated in the main world of the web contents.
+type Automation struct {
+	debugger *Debugger
+	evaluate func(ctx context.Context, code string, out interface{}) error
+	m        sync.Mutex // Locks timeout
+	timeout  time.Duration
+}
+
+// automationResult represents the result of an automation script
+type automationResult struct {
+	Disabled bool            `json:"disabled,omitempty"`
+	Found    bool            `json:"found"`
+	Value    json.RawMessage `json:"value,omitempty"`
+}
+
+// newAutomation creates a new automation
+func newAutomation(d *Debugger, evaluate func(ctx context.Context, code string, out interface{}) error) *Automation {
+	return &Automation{
+		debugger: d,
+		evaluate: evaluate,
+		timeout:  automationDefaultTimeout,
+	}
+}
+
+// SetTimeout sets how long actions wait for elements before failing, unless their context is done first
+func (a *Automation) SetTimeout(d time.Duration) {
+	a.m.Lock()
+	defer a.m.Unlock()
+	a.timeout = d
+}
+
+// Evaluate evaluates a JS expression and unmarshals its JSON result into result, unless result is nil
+// If the expression returns a promise, its resolved value is used. Exceptions are returned as *JavaScriptError.
+func (a *Automation) Evaluate(ctx context.Context, expr string, result interface{}) error {
+	return a.evaluate(ctx, expr, result)
+}
+
+// WaitForSelector waits for an element matching the selector to be attached to the DOM and visible
+func (a *Automation) WaitForSelector(ctx context.Context, selector string) (err error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+	_, err = a.poll(ctx, selector, "", "")
+	return
+}
+
+// Click waits for the element matching the selector and clicks on it
+// If the element stays disabled until the timeout, ErrElementDisabled is returned.
+func (a *Automation) Click(ctx context.Context, selector string) (err error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+	_, err = a.poll(ctx, selector, automationEnabled, "el.scrollIntoView({block: 'center', inline: 'center'}); el.click();")
+	return
+}
+
+// Type waits for the element matching the selector, focuses it and types text at the end of its current value
+// Input events are dispatched as if the text was typed by the user. If the element stays disabled until the timeout,
+// ErrElementDisabled is returned.
+func (a *Automation) Type(ctx context.Context, selector, text string) (err error) {
+	// Marshal text
+	var b []byte
+	if b, err = json.Marshal(text); err != nil {
+		err = fmt.Errorf("marshaling text failed: %w", err)
+		return
+	}
+
+	// Type
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+	_, err = a.poll(ctx, selector, automationEnabled, `el.focus();
+if (typeof el.setSelectionRange === 'function') { try { el.setSelectionRange(el.value.length, el.value.length); } catch (e) {} }
+if (!document.execCommand('insertText', false, `+string(b)+`)) {
+	el.value += `+string(b)+`;
+	el.dispatchEvent(new Event('input', {bubbles: true}));
+}
+el.dispatchEvent(new Event('change', {bubbles: true}));`)
+	return
+}
+
+// Text waits for the element matching the selector and returns its rendered text
+func (a *Automation) Text(ctx context.Context, selector string) (text string, err error) {
+	// Poll
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+	var r automationResult
+	if r, err = a.poll(ctx, selector, "", "return el.innerText !== undefined ? el.innerText : el.textContent;"); err != nil {
+		return
+	}
+
+	// Unmarshal
+	if err = json.Unmarshal(r.Value, &text); err != nil {
+		err = fmt.Errorf("unmarshaling %s failed: %w", r.Value, err)
+		return
+	}
+	return
+}
+
+// Screenshot returns a PNG screenshot of the page
+// It uses the Chrome DevTools Protocol: the debugger is attached during the screenshot if it's not already
+func (a *Automation) Screenshot(ctx context.Context) (b []byte, err error) {
+	// Check context
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	// Attach debugger
+	if !a.debugger.IsAttached() {
+		if err = a.debugger.Attach(""); err != nil {
+			err = fmt.Errorf("attaching debugger failed: %w", err)
+			return
+		}
+		defer func() {
+			if derr := a.debugger.Detach(); derr != nil && err == nil {
+				err = fmt.Errorf("detaching debugger failed: %w", derr)
+			}
+		}()
+	}
+
+	// Capture screenshot
+	var r struct {
+		Data string `json:"data"`
+	}
+	if err = a.debugger.SendCommand(ctx, "Page.captureScreenshot", map[string]string{"format": "png"}, &r); err != nil {
+		err = fmt.Errorf("capturing screenshot failed: %w", err)
+		return
+	}
+
+	// Decode
+	if b, err = base64.StdEncoding.DecodeString(r.Data); err != nil {
+		err = fmt.Errorf("decoding screenshot failed: %w", err)
+		return
+	}
+	return
+}
+
+// context returns the context used by actions waiting for elements
+func (a *Automation) context(parent context.Context) (context.Context, context.CancelFunc) {
+	a.m.Lock()
+	t := a.timeout
+	a.m.Unlock()
+	return context.WithTimeout(parent, t)
+}
+
+// poll executes an action on the element matching the selector as soon as it's attached to the DOM, visible and
+// passes the check
+func (a *Automation) poll(ctx context.Context, selector, check, action string) (r automationResult, err error) {
+	// Marshal selector
+	var b []byte
+	if b, err = json.Marshal(selector); err != nil {
+		err = fmt.Errorf("marshaling selector failed: %w", err)
+		return
+	}
+
+	// Create script
+	script := `(async () => {
+const el = document.querySelector(` + string(b) + `);
+if (!el || el.getClientRects().length === 0) { return {found: false}; }
+` + check + `
+return {found: true, value: await (async () => {
+` + action + `
+})()};
+})()`
+
+	// Loop
+	t := time.NewTicker(automationPollInterval)
+	defer t.Stop()
+	for {
+		// Evaluate
+		disabled := r.Disabled
+		r = automationResult{}
+		if err = a.evaluate(ctx, script, &r); err != nil {
+			if ctx.Err() != nil {
+				err = fmt.Errorf("waiting for %s failed: %w: %v", selector, automationError(disabled), err)
+			}
+			return
+		}
+
+		// Element has been found
+		if r.Found && !r.Disabled {
+			return
+		}
+
+		// Wait
+		select {
+		case <-ctx.Done():
+			err = fmt.Errorf("waiting for %s failed: %w: %v", selector, automationError(r.Disabled), ctx.Err())
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// automationError returns the error of an element that couldn't be found before the timeout, depending on whether it
+// was last seen disabled
+func automationError(disabled bool) error {
+	if disabled {
+		return ErrElementDisabled
+	}
+	return ErrElementNotFound
+}
@@ -0,0 +1,110 @@
+package astilectron
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutomation(t *testing.T) {
+	// Init
+	a, err := New(nil, Options{})
+	assert.NoError(t, err)
+	defer a.Close()
+	wrt := &mockedWriter{}
+	a.writer = newWriter(wrt, &logger{})
+	w, err := a.NewWindow("http://test.com", &WindowOptions{})
+	assert.NoError(t, err)
+	au := w.Automation()
+	au.SetTimeout(500 * time.Millisecond)
+
+	// Mock javascript execution
+	var missing int
+	var codes []string
+	wrt.fn = func() {
+		var e Event
+		json.Unmarshal([]byte(wrt.w[len(wrt.w)-1]), &e)
+		r := Event{CallbackID: e.CallbackID, TargetID: w.id}
+		switch e.Name {
+		case EventNameWindowCmdWebContentsExecuteJavaScript:
+			codes = append(codes, e.Code)
+			r.Name = EventNameWindowEventWebContentsExecutedJavaScript
+			switch {
+			case strings.Contains(e.Code, "#missing"):
+				r.CodeResult = "{\"result\":{\"found\":false}}"
+			case strings.Contains(e.Code, "#disabled") && strings.Contains(e.Code, "el.disabled"):
+				r.CodeResult = "{\"result\":{\"disabled\":true,\"found\":true}}"
+			case missing > 0:
+				missing--
+				r.CodeResult = "{\"result\":{\"found\":false}}"
+			case strings.Contains(e.Code, "throw"):
+				r.CodeResult = "{\"error\":{\"message\":\"boom\",\"name\":\"Error\"}}"
+			case strings.Contains(e.Code, "innerText"):
+				r.CodeResult = "{\"result\":{\"found\":true,\"value\":\"hello\"}}"
+			case strings.Contains(e.Code, "querySelector("):
+				r.CodeResult = "{\"result\":{\"found\":true,\"value\":null}}"
+			default:
+				r.CodeResult = "{\"result\":{\"a\":1}}"
+			}
+		case EventNameWebContentsCmdDebuggerAttach:
+			r.Name = EventNameWebContentsEventDebuggerAttached
+		case EventNameWebContentsCmdDebuggerDetach:
+			r.Name = EventNameWebContentsEventDebuggerDetached
+		case EventNameWebContentsCmdDebuggerSendCommand:
+			r.Debugger = &EventDebugger{Result: json.RawMessage("{\"data\":\"" + base64.StdEncoding.EncodeToString([]byte("png")) + "\"}")}
+			r.Name = EventNameWebContentsEventDebuggerSentCommand
+		}
+		w.d.dispatch(r)
+	}
+
+	// Evaluate
+	var r map[string]int
+	assert.NoError(t, au.Evaluate(context.Background(), "({a: 1})", &r))
+	assert.Equal(t, map[string]int{"a": 1}, r)
+	assert.Contains(t, codes[0], "await (({a: 1})\n")
+	assert.EqualError(t, au.Evaluate(context.Background(), "(() => { throw new Error('boom') })()", nil), "javascript error: Error: boom")
+
+	// Auto-waiting
+	codes = []string{}
+	missing = 2
+	assert.NoError(t, au.Click(context.Background(), "#button"))
+	assert.Len(t, codes, 3)
+	assert.Contains(t, codes[2], "document.querySelector(\"#button\")")
+	assert.Contains(t, codes[2], "el.click()")
+	missing = 1
+	assert.NoError(t, au.Type(context.Background(), "#input", "it's \"quoted\""))
+	assert.Contains(t, codes[len(codes)-1], "document.execCommand('insertText', false, \"it's \\\"quoted\\\"\")")
+	text, err := au.Text(context.Background(), "#text")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", text)
+
+	// Missing element
+	err = au.Click(context.Background(), "#missing")
+	assert.True(t, errors.Is(err, ErrElementNotFound))
+	assert.Contains(t, err.Error(), "#missing")
+	assert.True(t, errors.Is(au.WaitForSelector(context.Background(), "#missing"), ErrElementNotFound))
+
+	// Disabled element
+	assert.True(t, errors.Is(au.Click(context.Background(), "#disabled"), ErrElementDisabled))
+	assert.NoError(t, au.WaitForSelector(context.Background(), "#disabled"))
+
+	// Cancelled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = au.Text(ctx, "#text")
+	assert.Error(t, err)
+	_, err = au.Screenshot(ctx)
+	assert.EqualError(t, err, context.Canceled.Error())
+
+	// Screenshot
+	b, err := au.Screenshot(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("png"), b)
+	assert.False(t, w.Debugger().IsAttached())
+}
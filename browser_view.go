@@ -3,6 +3,7 @@ package astilectron
 import (
 	"context"
 	"fmt"
+	"image"
 	stdUrl "net/url"
 	"path/filepath"
 
@@ -10,37 +11,46 @@ import (
 )
 
 const (
-	EventNameBrowserViewCmdCreate                            = "browser.view.cmd.create"
-	EventNameBrowserViewCmdSetBounds                         = "browser.view.cmd.set.bounds"
-	EventNameBrowserViewEventSetBounds                       = "browser.view.event.set.bounds"
-	EventNameBrowserViewEventDidFinishLoad                   = "browser.view.event.did.finish.load"
-	EventNameBrowserViewCmdLoadURL                           = "browser.view.cmd.load.url"
-	EventNameBrowserViewEventLoadedURL                       = "browser.view.event.loaded.url"
-	EventNameBrowserViewCmdWebContentsExecuteJavaScript      = "browser.view.cmd.web.contents.execute.javascript"
-	EventNameBrowserViewEventWebContentsExecutedJavaScript   = "browser.view.event.web.contents.executed.javascript"
-	EventNameBrowserViewCmdGetBounds                         = "browser.view.cmd.get.bounds"
-	EventNameBrowserViewEventGetBounds                       = "browser.view.event.get.bounds"
-	EventNameBrowserViewCmdInterceptStringProtocol           = "browser.view.cmd.intercept.string.protocol"
-	EventNameBrowserViewEventInterceptStringProtocol         = "browser.view.event.intercept.string.protocol"
-	EventNameBrowserViewEventInterceptStringProtocolCallback = "browser.view.event.intercept.string.protocol.callback"
-	EventNameBrowserViewCmdSetBackgroundColor                = "browser.view.cmd.set.background.color"
-	EventNameBrowserViewEventSetBackgroundColor              = "browser.view.event.set.background.color"
-	EventNameBrowserViewCmdSetAutoResize                     = "browser.view.cmd.set.auto.resize"
-	EventNameBrowserViewEventSetAutoResize                   = "browser.view.event.set.auto.resize"
-	EventNameBrowserViewCmdSetProxy                          = "browser.view.cmd.web.contents.set.proxy"
-	EventNameBrowserViewEventSetProxy                        = "browser.view.event.web.contents.set.proxy"
-	EventNameBrowserViewCmdOpenDevTools                      = "browser.view.cmd.open.dev.tools"
-	EventNameBrowserViewCmdCloseDevTools                     = "browser.view.cmd.close.dev.tools"
-	EventNameBrowserViewCmdSetUserAgent                      = "browser.view.cmd.set.user.agent"
-	EventNameBrowserViewEventSetUserAgent                    = "browser.view.event.set.user.agent"
-	EventNameBrowserViewCmdUninterceptProtocol               = "browser.view.cmd.unintercept.string.protocol"
-	EventNameBrowserViewEventUninterceptProtocol             = "browser.view.event.unintercept.string.protocol"
+	EventNameBrowserViewCmdCreate                                         = "browser.view.cmd.create"
+	EventNameBrowserViewCmdSetBounds                                      = "browser.view.cmd.set.bounds"
+	EventNameBrowserViewEventSetBounds                                    = "browser.view.event.set.bounds"
+	EventNameBrowserViewEventDidFinishLoad                                = "browser.view.event.did.finish.load"
+	EventNameBrowserViewCmdLoadURL                                        = "browser.view.cmd.load.url"
+	EventNameBrowserViewEventLoadedURL                                    = "browser.view.event.loaded.url"
+	EventNameBrowserViewCmdWebContentsExecuteJavaScript                   = "browser.view.cmd.web.contents.execute.javascript"
+	EventNameBrowserViewEventWebContentsExecutedJavaScript                = "browser.view.event.web.contents.executed.javascript"
+	EventNameBrowserViewCmdWebContentsExecuteJavaScriptInIsolatedWorld    = "browser.view.cmd.web.contents.execute.javascript.in.isolated.world"
+	EventNameBrowserViewEventWebContentsExecutedJavaScriptInIsolatedWorld = "browser.view.event.web.contents.executed.javascript.in.isolated.world"
+	EventNameBrowserViewCmdGetBounds                                      = "browser.view.cmd.get.bounds"
+	EventNameBrowserViewEventGetBounds                                    = "browser.view.event.get.bounds"
+	EventNameBrowserViewCmdInterceptStringProtocol                        = "browser.view.cmd.intercept.string.protocol"
+	EventNameBrowserViewEventInterceptStringProtocol                      = "browser.view.event.intercept.string.protocol"
+	EventNameBrowserViewEventInterceptStringProtocolCallback              = "browser.view.event.intercept.string.protocol.callback"
+	EventNameBrowserViewCmdSetBackgroundColor                             = "browser.view.cmd.set.background.color"
+	EventNameBrowserViewEventSetBackgroundColor                           = "browser.view.event.set.background.color"
+	EventNameBrowserViewCmdSetAutoResize                                  = "browser.view.cmd.set.auto.resize"
+	EventNameBrowserViewEventSetAutoResize                                = "browser.view.event.set.auto.resize"
+	EventNameBrowserViewCmdSetProxy                                       = "browser.view.cmd.web.contents.set.proxy"
+	EventNameBrowserViewEventSetProxy                                     = "browser.view.event.web.contents.set.proxy"
+	EventNameBrowserViewCmdOpenDevTools                                   = "browser.view.cmd.open.dev.tools"
+	EventNameBrowserViewCmdCloseDevTools                                  = "browser.view.cmd.close.dev.tools"
+	EventNameBrowserViewCmdWebContentsCapturePage                         = "browser.view.cmd.web.contents.capture.page"
+	EventNameBrowserViewEventWebContentsCapturedPage                      = "browser.view.event.web.contents.captured.page"
+	EventNameBrowserViewCmdWebContentsPrintToPDF                          = "browser.view.cmd.web.contents.print.to.pdf"
+	EventNameBrowserViewEventWebContentsPrintedToPDF                      = "browser.view.event.web.contents.printed.to.pdf"
+	EventNameBrowserViewCmdSetUserAgent                                   = "browser.view.cmd.set.user.agent"
+	EventNameBrowserViewEventSetUserAgent                                 = "browser.view.event.set.user.agent"
+	EventNameBrowserViewCmdUninterceptProtocol                            = "browser.view.cmd.unintercept.string.protocol"
+	EventNameBrowserViewEventUninterceptProtocol                          = "browser.view.event.unintercept.string.protocol"
 )
 
 type BrowserView struct {
 	*object
+	automation         *Automation
 	callbackIdentifier *identifier
+	debugger           *Debugger
 	l                  astikit.SeverityLogger
+	navigation         *navigation
 	o                  *WindowOptions
 	url                *stdUrl.URL
 	ID                 string
@@ -57,8 +67,25 @@ func newBrowserView(ctx context.Context, l astikit.SeverityLogger, o Options, p
 		object:             newObject(ctx, d, i, wrt, id),
 		ID:                 id,
 	}
+	b.debugger = newDebugger(b.object)
+	b.navigation = newNavigation(b.object, l, browserViewNavigationEventNames, nil)
+	b.automation = newAutomation(b.debugger, b.Evaluate)
 
+	if wo != nil {
+		if err = secureWindowOptions(wo, p); err != nil {
+			err = fmt.Errorf("securing window options failed: %w", err)
+			return
+		}
+	}
+
+	if s == nil && wo != nil {
+		s = wo.Session
+	}
 	b.Session = s
+	if s != nil && wo != nil {
+		wo.Session = s
+		useSession(wo)
+	}
 
 	if url != "" {
 		// Basic parse
@@ -141,7 +168,21 @@ func (b *BrowserView) LoadURL(url string, load *Load) (err error) {
 	return
 }
 
-//todo: code can only return as string rn
+// Evaluate evaluates a JS expression in the main world of the browser view and decodes its JSON serialized result into
+// out, unless out is nil
+// If the expression returns a promise, its resolved value is used. Exceptions are returned as *JavaScriptError.
+func (b *BrowserView) Evaluate(ctx context.Context, code string, out interface{}) error {
+	return evaluateJavaScript(ctx, b.object, EventNameBrowserViewCmdWebContentsExecuteJavaScript, EventNameBrowserViewEventWebContentsExecutedJavaScript, nil, code, out)
+}
+
+// EvaluateInIsolatedWorld is the same as Evaluate except the expression is evaluated in an isolated world, which shares
+// the DOM of the page but not its JS globals
+func (b *BrowserView) EvaluateInIsolatedWorld(ctx context.Context, worldID int, code string, out interface{}) error {
+	return evaluateJavaScript(ctx, b.object, EventNameBrowserViewCmdWebContentsExecuteJavaScriptInIsolatedWorld, EventNameBrowserViewEventWebContentsExecutedJavaScriptInIsolatedWorld, &worldID, code, out)
+}
+
+// ExecuteJavaScript executes some js and returns its result as a string in CodeResult
+// Use Evaluate to get typed results
 func (b *BrowserView) ExecuteJavaScript(code string) (e Event, err error) {
 	if err = b.ctx.Err(); err != nil {
 		return
@@ -180,6 +221,90 @@ func (b *BrowserView) SetProxy(proxy *WindowProxyOptions) (err error) {
 	return
 }
 
+// Automation returns the object capable of driving the browser view's web contents
+func (b *BrowserView) Automation() *Automation {
+	return b.automation
+}
+
+// CapturePage captures a snapshot of the browser view's page as PNG bytes
+// A nil rect means the whole visible page is captured.
+func (b *BrowserView) CapturePage(ctx context.Context, rect *RectangleOptions) ([]byte, error) {
+	return capturePage(ctx, b.object, EventNameBrowserViewCmdWebContentsCapturePage, EventNameBrowserViewEventWebContentsCapturedPage, rect)
+}
+
+// CapturePageImage is the same as CapturePage except the snapshot is decoded into an image
+func (b *BrowserView) CapturePageImage(ctx context.Context, rect *RectangleOptions) (image.Image, error) {
+	return capturePageImage(ctx, b.object, EventNameBrowserViewCmdWebContentsCapturePage, EventNameBrowserViewEventWebContentsCapturedPage, rect)
+}
+
+// PrintToPDF prints the browser view's page as PDF bytes
+func (b *BrowserView) PrintToPDF(ctx context.Context, o PrintToPDFOptions) ([]byte, error) {
+	return printToPDF(ctx, b.object, EventNameBrowserViewCmdWebContentsPrintToPDF, EventNameBrowserViewEventWebContentsPrintedToPDF, o)
+}
+
+// Debugger returns the Chrome DevTools Protocol debugger of the browser view's web contents
+func (b *BrowserView) Debugger() *Debugger {
+	return b.debugger
+}
+
+// GoBack makes the browser view go back to the previous page
+func (b *BrowserView) GoBack(ctx context.Context) error {
+	return b.navigation.goBack(ctx)
+}
+
+// GoForward makes the browser view go forward to the next page
+func (b *BrowserView) GoForward(ctx context.Context) error {
+	return b.navigation.goForward(ctx)
+}
+
+// GoToIndex makes the browser view navigate to an entry of its navigation history
+func (b *BrowserView) GoToIndex(ctx context.Context, index int) error {
+	return b.navigation.goToIndex(ctx, index)
+}
+
+// CanGoBack returns whether the browser view can go back to a previous page
+func (b *BrowserView) CanGoBack(ctx context.Context) (bool, error) {
+	h, err := b.navigation.history(ctx)
+	return h.CanGoBack(), err
+}
+
+// CanGoForward returns whether the browser view can go forward to a next page
+func (b *BrowserView) CanGoForward(ctx context.Context) (bool, error) {
+	h, err := b.navigation.history(ctx)
+	return h.CanGoForward(), err
+}
+
+// NavigationHistory returns the navigation history of the browser view
+func (b *BrowserView) NavigationHistory(ctx context.Context) (NavigationHistory, error) {
+	return b.navigation.history(ctx)
+}
+
+// Reload reloads the browser view's page
+func (b *BrowserView) Reload(ctx context.Context) error {
+	return b.navigation.reload(ctx)
+}
+
+// ReloadIgnoringCache reloads the browser view's page without using the cache
+func (b *BrowserView) ReloadIgnoringCache(ctx context.Context) error {
+	return b.navigation.reloadIgnoringCache(ctx)
+}
+
+// Stop stops any pending navigation of the browser view
+func (b *BrowserView) Stop(ctx context.Context) error {
+	return b.navigation.stop(ctx)
+}
+
+// OnWillNavigate adds a hook deciding whether the browser view can navigate to a url when the navigation is initiated by the
+// page (e.g. links, window.location), which is not the case of LoadURL, GoBack, GoForward, GoToIndex or Reload
+// The navigation is prevented as soon as one hook doesn't allow it. Electron can't wait for the hooks' decision: it
+// prevents the navigation and, if it's allowed, replays it by loading its url, which drops POST bodies and the
+// referrer. Once navigations have happened, Electron emits EventNameBrowserViewEventWebContentsDidNavigate and, for in-page
+// navigations such as anchors or the history API, EventNameBrowserViewEventWebContentsDidNavigateInPage, with the new url
+// in Event.URL.
+func (b *BrowserView) OnWillNavigate(fn func(url string) (allow bool)) {
+	b.navigation.onWillNavigate(fn)
+}
+
 func (b *BrowserView) OpenDevTools() (err error) {
 	if err = b.ctx.Err(); err != nil {
 		return
@@ -0,0 +1,232 @@
+package astilectron
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// Certificate event names
+const (
+	EventNameAppCmdOnCertificateError              = "app.cmd.on.certificate.error"
+	EventNameAppEventCertificateError              = "app.event.certificate.error"
+	EventNameAppEventCertificateErrorCallback      = "app.event.certificate.error.callback"
+	EventNameSessionCmdSetCertificateVerifyProc    = "session.cmd.set.certificate.verify.proc"
+	EventNameSessionCmdUnsetCertificateVerifyProc  = "session.cmd.unset.certificate.verify.proc"
+	EventNameSessionEventCertificateVerify         = "session.event.certificate.verify"
+	EventNameSessionEventCertificateVerifyCallback = "session.event.certificate.verify.callback"
+)
+
+// Certificate verify results
+// https://github.com/electron/electron/blob/v11.4.3/docs/api/session.md#sessetcertificateverifyprocproc
+const (
+	CertificateVerifyResultReject      = -2 // Rejects the certificate
+	CertificateVerifyResultTrust       = 0  // Trusts the certificate and disables Certificate Transparency verification
+	CertificateVerifyResultUseChromium = -3 // Uses the verification result from Chromium
+)
+
+// Certificate represents a certificate
+// https://github.com/electron/electron/blob/v11.4.3/docs/api/structures/certificate.md
+type Certificate struct {
+	Data         string               `json:"data"` // PEM encoded data
+	Fingerprint  string               `json:"fingerprint,omitempty"`
+	Issuer       CertificatePrincipal `json:"issuer"`
+	IssuerCert   *Certificate         `json:"issuerCert,omitempty"`
+	IssuerName   string               `json:"issuerName,omitempty"`
+	SerialNumber string               `json:"serialNumber,omitempty"`
+	Subject      CertificatePrincipal `json:"subject"`
+	SubjectName  string               `json:"subjectName,omitempty"`
+	ValidExpiry  float64              `json:"validExpiry,omitempty"` // In seconds since the UNIX epoch
+	ValidStart   float64              `json:"validStart,omitempty"`  // In seconds since the UNIX epoch
+}
+
+// CertificatePrincipal represents a certificate principal
+// https://github.com/electron/electron/blob/v11.4.3/docs/api/structures/certificate-principal.md
+type CertificatePrincipal struct {
+	CommonName        string   `json:"commonName,omitempty"`
+	Country           string   `json:"country,omitempty"`
+	Locality          string   `json:"locality,omitempty"`
+	Organizations     []string `json:"organizations,omitempty"`
+	OrganizationUnits []string `json:"organizationUnits,omitempty"`
+	State             string   `json:"state,omitempty"`
+}
+
+// X509 parses the certificate's PEM encoded data
+func (c Certificate) X509() (x *x509.Certificate, err error) {
+	// Decode PEM
+	b, _ := pem.Decode([]byte(c.Data))
+	if b == nil {
+		err = errors.New("no PEM data found")
+		return
+	}
+
+	// Parse
+	if x, err = x509.ParseCertificate(b.Bytes); err != nil {
+		err = fmt.Errorf("parsing certificate failed: %w", err)
+		return
+	}
+	return
+}
+
+// Chain parses the certificate as well as its issuers, starting with the certificate itself
+func (c Certificate) Chain() (xs []*x509.Certificate, err error) {
+	for i := &c; i != nil; i = i.IssuerCert {
+		var x *x509.Certificate
+		if x, err = i.X509(); err != nil {
+			err = fmt.Errorf("parsing certificate %d of the chain failed: %w", len(xs), err)
+			return
+		}
+		xs = append(xs, x)
+	}
+	return
+}
+
+// Verify verifies the certificate chain with GO's crypto/x509 for a hostname
+// Issuers of the chain are used as intermediates. Nil roots means the system roots are used.
+func (c Certificate) Verify(hostname string, roots *x509.CertPool) (err error) {
+	// Parse chain
+	var xs []*x509.Certificate
+	if xs, err = c.Chain(); err != nil {
+		return
+	}
+
+	// Verify
+	is := x509.NewCertPool()
+	for _, x := range xs[1:] {
+		is.AddCert(x)
+	}
+	if _, err = xs[0].Verify(x509.VerifyOptions{
+		DNSName:       hostname,
+		Intermediates: is,
+		Roots:         roots,
+	}); err != nil {
+		err = fmt.Errorf("verifying certificate failed: %w", err)
+		return
+	}
+	return
+}
+
+// CertificateVerifyRequest represents a certificate verification request
+// VerificationResult and ErrorCode are the result of Chromium's verification
+type CertificateVerifyRequest struct {
+	Certificate          Certificate `json:"certificate"`
+	ErrorCode            int         `json:"errorCode,omitempty"`
+	Hostname             string      `json:"hostname"`
+	ValidatedCertificate Certificate `json:"validatedCertificate"`
+	VerificationResult   string      `json:"verificationResult,omitempty"`
+}
+
+// CertificateError represents a certificate error
+// WindowID is the id of the window the request originates from, if any
+type CertificateError struct {
+	Certificate   Certificate `json:"certificate"`
+	Error         string      `json:"error,omitempty"`
+	URL           string      `json:"url,omitempty"`
+	WebContentsID *int        `json:"webContentsId,omitempty"`
+	WindowID      string      `json:"windowId,omitempty"`
+}
+
+// SetCertificateVerifyProc sets the proc verifying the server certificates of the session
+// The proc returns one of the CertificateVerifyResult constants. A nil proc restores Chromium's verification.
+func (s *Session) SetCertificateVerifyProc(fn func(r CertificateVerifyRequest) (result int)) (err error) {
+	// Check context
+	if err = s.ctx.Err(); err != nil {
+		return
+	}
+
+	// Store proc
+	s.m.Lock()
+	s.certificateVerifyProc = fn
+	listening := s.certificateVerifyListening
+	if fn != nil {
+		s.certificateVerifyListening = true
+	}
+	s.m.Unlock()
+
+	// Unset
+	if fn == nil {
+		return s.w.write(Event{Name: EventNameSessionCmdUnsetCertificateVerifyProc, TargetID: s.id})
+	}
+
+	// Listen
+	if !listening {
+		s.On(EventNameSessionEventCertificateVerify, func(e Event) (deleteListener bool) {
+			s.handleCertificateVerify(e)
+			return
+		})
+	}
+	return s.w.write(Event{Name: EventNameSessionCmdSetCertificateVerifyProc, TargetID: s.id})
+}
+
+// handleCertificateVerify lets the proc verify the certificate and sends the result back to Electron
+func (s *Session) handleCertificateVerify(e Event) {
+	// Get proc
+	s.m.Lock()
+	fn := s.certificateVerifyProc
+	s.m.Unlock()
+
+	// Verify, falling back to Chromium's verification if the proc has been unset in the meantime
+	r := CertificateVerifyResultUseChromium
+	if fn != nil {
+		var i CertificateVerifyRequest
+		if e.CertificateVerify != nil {
+			i = *e.CertificateVerify
+		}
+		r = fn(i)
+	}
+
+	// Send result back
+	if err := s.w.write(Event{CallbackID: e.CallbackID, Name: EventNameSessionEventCertificateVerifyCallback, TargetID: s.id, VerificationResult: &r}); err != nil {
+		s.l.Error(fmt.Errorf("writing %s event failed: %w", EventNameSessionEventCertificateVerifyCallback, err))
+	}
+}
+
+// OnCertificateError sets the handler deciding whether certificates that failed verification are trusted anyway
+// Only the last handler set is used. Without handler, such certificates are not trusted.
+func (a *Astilectron) OnCertificateError(fn func(e CertificateError) (trusted bool)) (err error) {
+	// Store handler
+	a.m.Lock()
+	listening := a.certificateErrorListening
+	a.certificateErrorHandler = fn
+	a.certificateErrorListening = true
+	a.m.Unlock()
+
+	// Listen
+	if !listening {
+		a.On(EventNameAppEventCertificateError, func(e Event) (deleteListener bool) {
+			a.handleCertificateError(e)
+			return
+		})
+	}
+
+	// Check context
+	if err = a.worker.Context().Err(); err != nil {
+		return
+	}
+	return a.writer.write(Event{Name: EventNameAppCmdOnCertificateError})
+}
+
+// handleCertificateError lets the handler decide whether the certificate is trusted and sends the decision back to
+// Electron
+func (a *Astilectron) handleCertificateError(e Event) {
+	// Get handler
+	a.m.Lock()
+	fn := a.certificateErrorHandler
+	a.m.Unlock()
+
+	// Decide
+	var trusted bool
+	if fn != nil {
+		var i CertificateError
+		if e.CertificateError != nil {
+			i = *e.CertificateError
+		}
+		trusted = fn(i)
+	}
+
+	// Send decision back
+	if err := a.writer.write(Event{CallbackID: e.CallbackID, Name: EventNameAppEventCertificateErrorCallback, Trusted: &trusted}); err != nil {
+		a.l.Error(fmt.Errorf("writing %s event failed: %w", EventNameAppEventCertificateErrorCallback, err))
+	}
+}
@@ -0,0 +1,122 @@
+package astilectron
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testCertificate(t *testing.T, template, parent *x509.Certificate, key, parentKey *ecdsa.PrivateKey) (*x509.Certificate, string) {
+	b, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+	c, err := x509.ParseCertificate(b)
+	assert.NoError(t, err)
+	return c, string(pem.EncodeToMemory(&pem.Block{Bytes: b, Type: "CERTIFICATE"}))
+}
+
+func TestCertificate(t *testing.T) {
+	// Create chain
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	ca := &x509.Certificate{
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		NotAfter:              time.Now().Add(time.Hour),
+		NotBefore:             time.Now().Add(-time.Hour),
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+	}
+	ca, caPEM := testCertificate(t, ca, ca, caKey, caKey)
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	_, leafPEM := testCertificate(t, &x509.Certificate{
+		DNSNames:     []string{"api.example.com"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		NotAfter:     time.Now().Add(time.Hour),
+		NotBefore:    time.Now().Add(-time.Hour),
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "api.example.com"},
+	}, ca, leafKey, caKey)
+	c := Certificate{Data: leafPEM, IssuerCert: &Certificate{Data: caPEM}}
+
+	// Chain
+	xs, err := c.Chain()
+	assert.NoError(t, err)
+	assert.Len(t, xs, 2)
+	assert.Equal(t, "api.example.com", xs[0].Subject.CommonName)
+	assert.Equal(t, "ca", xs[1].Subject.CommonName)
+	_, err = Certificate{Data: "invalid"}.Chain()
+	assert.Error(t, err)
+
+	// Verify
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	assert.NoError(t, Certificate{Data: leafPEM}.Verify("api.example.com", roots))
+	assert.Error(t, c.Verify("other.example.com", roots))
+	assert.Error(t, c.Verify("api.example.com", x509.NewCertPool()))
+}
+
+func TestSession_SetCertificateVerifyProc(t *testing.T) {
+	// Init
+	d := newDispatcher()
+	i := newIdentifier()
+	wrt := &mockedWriter{}
+	w := newWriter(wrt, &logger{})
+	s := newSession(context.Background(), &logger{}, d, i, w)
+
+	// Set
+	assert.NoError(t, s.SetCertificateVerifyProc(func(r CertificateVerifyRequest) int {
+		if r.Hostname == "api.example.com" {
+			return CertificateVerifyResultReject
+		}
+		return CertificateVerifyResultUseChromium
+	}))
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameSessionCmdSetCertificateVerifyProc + "\",\"targetID\":\"1\"}\n"}, wrt.w)
+
+	// Verify
+	wrt.w = []string{}
+	wrt.wg = &sync.WaitGroup{}
+	wrt.wg.Add(1)
+	d.dispatch(Event{CallbackID: "1", CertificateVerify: &CertificateVerifyRequest{Hostname: "api.example.com"}, Name: EventNameSessionEventCertificateVerify, TargetID: s.id})
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameSessionEventCertificateVerifyCallback + "\",\"targetID\":\"1\",\"callbackId\":\"1\",\"verificationResult\":-2}\n"}, wrt.w)
+
+	// Unset
+	wrt.w = []string{}
+	wrt.wg.Add(1)
+	assert.NoError(t, s.SetCertificateVerifyProc(nil))
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameSessionCmdUnsetCertificateVerifyProc + "\",\"targetID\":\"1\"}\n"}, wrt.w)
+}
+
+func TestAstilectron_OnCertificateError(t *testing.T) {
+	// Init
+	a, err := New(nil, Options{})
+	assert.NoError(t, err)
+	defer a.Close()
+	wrt := &mockedWriter{}
+	a.writer = newWriter(wrt, &logger{})
+
+	// Set
+	assert.NoError(t, a.OnCertificateError(func(e CertificateError) bool { return e.Certificate.Fingerprint == "sha256/proxy" }))
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameAppCmdOnCertificateError + "\"}\n"}, wrt.w)
+
+	// Certificate error
+	wrt.w = []string{}
+	wrt.wg = &sync.WaitGroup{}
+	wrt.wg.Add(1)
+	a.dispatcher.dispatch(Event{CallbackID: "1", CertificateError: &CertificateError{Certificate: Certificate{Fingerprint: "sha256/proxy"}, Error: "net::ERR_CERT_AUTHORITY_INVALID"}, Name: EventNameAppEventCertificateError, TargetID: targetIDApp})
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameAppEventCertificateErrorCallback + "\",\"callbackId\":\"1\",\"trusted\":true}\n"}, wrt.w)
+}
@@ -0,0 +1,114 @@
+package astilectron
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Close request event names
+const (
+	EventNameAppCmdSetBeforeQuitHandler      = "app.cmd.set.before.quit.handler"
+	EventNameAppEventBeforeQuit              = "app.event.before.quit"
+	EventNameAppEventBeforeQuitCallback      = "app.event.before.quit.callback"
+	EventNameWindowCmdSetCloseRequestHandler = "window.cmd.set.close.request.handler"
+	EventNameWindowEventCloseRequest         = "window.event.close.request"
+	EventNameWindowEventCloseRequestCallback = "window.event.close.request.callback"
+)
+
+// closeHooks represents hooks deciding whether something can be closed
+type closeHooks struct {
+	fns []func(ctx context.Context) (allow bool)
+	m   sync.Mutex
+}
+
+// add adds a hook and returns whether it's the first one
+func (h *closeHooks) add(fn func(ctx context.Context) (allow bool)) (first bool) {
+	h.m.Lock()
+	defer h.m.Unlock()
+	h.fns = append(h.fns, fn)
+	return len(h.fns) == 1
+}
+
+// empty returns whether no hook has been added
+func (h *closeHooks) empty() bool {
+	h.m.Lock()
+	defer h.m.Unlock()
+	return len(h.fns) == 0
+}
+
+// allow executes the hooks in the order they were added and returns whether they all allow the close
+// Hooks following a hook preventing the close are not executed.
+func (h *closeHooks) allow(ctx context.Context) bool {
+	h.m.Lock()
+	fns := append([]func(ctx context.Context) bool{}, h.fns...)
+	h.m.Unlock()
+	for _, fn := range fns {
+		if !fn(ctx) {
+			return false
+		}
+	}
+	return true
+}
+
+// OnCloseRequest adds a hook deciding whether the window can be closed when the user or the page requests it
+// Electron pauses the close until every hook has been executed, which lets hooks check the page's state or show a
+// dialog. The close is prevented as soon as one hook doesn't allow it. The context is cancelled if the window is
+// closed in the meantime, e.g. with Destroy. Hooks are executed before WindowCustomOptions are applied. Hooks are not
+// executed when the window is destroyed by GO, e.g. with Destroy, Astilectron.Stop, Astilectron.Close or a signal
+// handled by Astilectron.HandleSignals, in which case GO must shut down orderly before. Hooks can be added before the
+// window is created.
+func (w *Window) OnCloseRequest(fn func(ctx context.Context) (allow bool)) (err error) {
+	// Add hook
+	if !w.closeHooks.add(fn) {
+		return
+	}
+
+	// Listen
+	w.On(EventNameWindowEventCloseRequest, func(e Event) (deleteListener bool) {
+		allowed := w.closeHooks.allow(w.ctx)
+		if err := w.w.write(Event{Allowed: &allowed, CallbackID: e.CallbackID, Name: EventNameWindowEventCloseRequestCallback, TargetID: w.id}); err != nil {
+			w.l.Error(fmt.Errorf("writing %s event failed: %w", EventNameWindowEventCloseRequestCallback, err))
+		}
+		return
+	})
+
+	// Check context
+	if err = w.ctx.Err(); err != nil {
+		return
+	}
+
+	// Window has not been created yet, in which case the handler is set when it is
+	if !w.isCreated() {
+		return
+	}
+	return w.w.write(Event{Name: EventNameWindowCmdSetCloseRequestHandler, TargetID: w.id})
+}
+
+// OnBeforeQuit adds a hook executed before the app quits, including when Quit is called, which lets GO shut down
+// orderly or prevent the quit
+// Electron pauses the quit until every hook has been executed. The quit is prevented as soon as one hook doesn't
+// allow it. Windows' close request hooks are executed afterwards, when Electron closes the windows. Hooks are not
+// executed when GO stops the app, i.e. with Stop, Close or a signal handled by HandleSignals: use Quit instead for
+// hooks to be executed.
+func (a *Astilectron) OnBeforeQuit(fn func(ctx context.Context) (allow bool)) (err error) {
+	// Add hook
+	if !a.beforeQuitHooks.add(fn) {
+		return
+	}
+
+	// Listen
+	a.On(EventNameAppEventBeforeQuit, func(e Event) (deleteListener bool) {
+		allowed := a.beforeQuitHooks.allow(a.worker.Context())
+		if err := a.writer.write(Event{Allowed: &allowed, CallbackID: e.CallbackID, Name: EventNameAppEventBeforeQuitCallback}); err != nil {
+			a.l.Error(fmt.Errorf("writing %s event failed: %w", EventNameAppEventBeforeQuitCallback, err))
+		}
+		return
+	})
+
+	// Check context
+	if err = a.worker.Context().Err(); err != nil {
+		return
+	}
+	return a.writer.write(Event{Name: EventNameAppCmdSetBeforeQuitHandler})
+}
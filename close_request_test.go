@@ -0,0 +1,117 @@
+package astilectron
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindow_OnCloseRequest(t *testing.T) {
+	// Init
+	a, err := New(nil, Options{})
+	assert.NoError(t, err)
+	defer a.Close()
+	wrt := &mockedWriter{wg: &sync.WaitGroup{}}
+	a.writer = newWriter(wrt, &logger{})
+	w, err := a.NewWindow("http://test.com", &WindowOptions{})
+	assert.NoError(t, err)
+	w.created = true
+
+	// Add hooks
+	var m sync.Mutex
+	var calls []int
+	saved := false
+	wrt.wg.Add(1)
+	assert.NoError(t, w.OnCloseRequest(func(ctx context.Context) bool {
+		m.Lock()
+		defer m.Unlock()
+		calls = append(calls, 1)
+		return saved
+	}))
+	assert.NoError(t, w.OnCloseRequest(func(ctx context.Context) bool {
+		m.Lock()
+		defer m.Unlock()
+		calls = append(calls, 2)
+		return true
+	}))
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameWindowCmdSetCloseRequestHandler + "\",\"targetID\":\"" + w.id + "\"}\n"}, wrt.w)
+
+	// Close is prevented
+	wrt.w = []string{}
+	wrt.wg.Add(1)
+	a.dispatcher.dispatch(Event{CallbackID: "1", Name: EventNameWindowEventCloseRequest, TargetID: w.id})
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameWindowEventCloseRequestCallback + "\",\"targetID\":\"" + w.id + "\",\"allowed\":false,\"callbackId\":\"1\"}\n"}, wrt.w)
+	assert.Equal(t, []int{1}, calls)
+
+	// Close is allowed
+	m.Lock()
+	saved = true
+	m.Unlock()
+	wrt.w = []string{}
+	wrt.wg.Add(1)
+	a.dispatcher.dispatch(Event{CallbackID: "2", Name: EventNameWindowEventCloseRequest, TargetID: w.id})
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameWindowEventCloseRequestCallback + "\",\"targetID\":\"" + w.id + "\",\"allowed\":true,\"callbackId\":\"2\"}\n"}, wrt.w)
+	assert.Equal(t, []int{1, 1, 2}, calls)
+}
+
+func TestWindow_OnCloseRequestBeforeCreate(t *testing.T) {
+	// Init
+	a, err := New(nil, Options{})
+	assert.NoError(t, err)
+	defer a.Close()
+	wrt := &mockedWriter{}
+	a.writer = newWriter(wrt, &logger{})
+	w, err := a.NewWindow("http://test.com", &WindowOptions{})
+	assert.NoError(t, err)
+	wrt.fn = func() {
+		var e Event
+		json.Unmarshal([]byte(wrt.w[len(wrt.w)-1]), &e)
+		if e.Name == EventNameWindowCmdCreate {
+			a.dispatcher.dispatch(Event{Name: EventNameWindowEventDidFinishLoad, TargetID: w.id})
+		}
+	}
+
+	// Add hook
+	assert.NoError(t, w.OnCloseRequest(func(ctx context.Context) bool { return false }))
+	assert.Empty(t, wrt.w)
+
+	// Create
+	assert.NoError(t, w.Create())
+	assert.Len(t, wrt.w, 1)
+	var e Event
+	assert.NoError(t, json.Unmarshal([]byte(wrt.w[0]), &e))
+	assert.Equal(t, []string{EventNameWindowCmdSetCloseRequestHandler}, e.Handlers)
+	wrt.fn = nil
+}
+
+func TestAstilectron_OnBeforeQuit(t *testing.T) {
+	// Init
+	a, err := New(nil, Options{})
+	assert.NoError(t, err)
+	defer a.Close()
+	wrt := &mockedWriter{wg: &sync.WaitGroup{}}
+	a.writer = newWriter(wrt, &logger{})
+
+	// Add hook
+	var done bool
+	wrt.wg.Add(1)
+	assert.NoError(t, a.OnBeforeQuit(func(ctx context.Context) bool {
+		done = true
+		return true
+	}))
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameAppCmdSetBeforeQuitHandler + "\"}\n"}, wrt.w)
+
+	// Before quit
+	wrt.w = []string{}
+	wrt.wg.Add(1)
+	a.dispatcher.dispatch(Event{CallbackID: "1", Name: EventNameAppEventBeforeQuit, TargetID: targetIDApp})
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameAppEventBeforeQuitCallback + "\",\"allowed\":true,\"callbackId\":\"1\"}\n"}, wrt.w)
+	assert.True(t, done)
+}
@@ -0,0 +1,212 @@
+package astilectron
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Debugger event names
+const (
+	EventNameWebContentsCmdDebuggerAttach        = "web.contents.cmd.debugger.attach"
+	EventNameWebContentsCmdDebuggerDetach        = "web.contents.cmd.debugger.detach"
+	EventNameWebContentsCmdDebuggerSendCommand   = "web.contents.cmd.debugger.send.command"
+	EventNameWebContentsEventDebuggerAttached    = "web.contents.event.debugger.attached"
+	EventNameWebContentsEventDebuggerDetached    = "web.contents.event.debugger.detached"
+	EventNameWebContentsEventDebuggerMessage     = "web.contents.event.debugger.message"
+	EventNameWebContentsEventDebuggerSentCommand = "web.contents.event.debugger.sent.command"
+)
+
+// Debugger represents the Chrome DevTools Protocol debugger of a window or a browser view's web contents
+// https://github.com/electron/electron/blob/v11.4.3/docs/api/debugger.md
+// https://chromedevtools.github.io/devtools-protocol/
+type Debugger struct {
+	attached  bool
+	id        int // Last listener id
+	listeners []debuggerListener
+	m         sync.Mutex // Locks attached, id, listeners, q and running
+	o         *object
+	q         []DebuggerEvent
+	running   bool // Whether a goroutine is delivering queued events
+}
+
+// debuggerListener represents a listener of CDP events
+type debuggerListener struct {
+	fn     func(e DebuggerEvent) (deleteListener bool)
+	id     int
+	method string
+}
+
+// DebuggerEvent represents a CDP event
+// SessionID is the id of the CDP session the event originates from, if any
+type DebuggerEvent struct {
+	Method    string
+	Params    json.RawMessage
+	SessionID string
+}
+
+// newDebugger creates a new debugger
+func newDebugger(o *object) (d *Debugger) {
+	d = &Debugger{o: o}
+	o.d.setOrderedHandler(o.id, EventNameWebContentsEventDebuggerMessage, d.enqueue)
+	o.On(EventNameWebContentsEventDebuggerDetached, func(e Event) (deleteListener bool) {
+		d.m.Lock()
+		d.attached = false
+		d.m.Unlock()
+		return
+	})
+	return
+}
+
+// Attach attaches the debugger to the web contents
+// An empty protocol version means the latest version is used.
+func (d *Debugger) Attach(protocolVersion string) (err error) {
+	if _, err = synchronousRequest(context.Background(), d.o, Event{Debugger: &EventDebugger{Version: protocolVersion}, Name: EventNameWebContentsCmdDebuggerAttach, TargetID: d.o.id}, EventNameWebContentsEventDebuggerAttached); err != nil {
+		return
+	}
+	d.m.Lock()
+	d.attached = true
+	d.m.Unlock()
+	return
+}
+
+// Detach detaches the debugger from the web contents
+func (d *Debugger) Detach() (err error) {
+	if _, err = synchronousRequest(context.Background(), d.o, Event{Name: EventNameWebContentsCmdDebuggerDetach, TargetID: d.o.id}, EventNameWebContentsEventDebuggerDetached); err != nil {
+		return
+	}
+	d.m.Lock()
+	d.attached = false
+	d.m.Unlock()
+	return
+}
+
+// IsAttached returns whether the debugger is attached to the web contents
+func (d *Debugger) IsAttached() bool {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return d.attached
+}
+
+// SendCommand sends a CDP command and unmarshals its result into result, unless result is nil
+// Params are marshaled into JSON and can be nil.
+func (d *Debugger) SendCommand(ctx context.Context, method string, params, result interface{}) error {
+	return d.SendSessionCommand(ctx, "", method, params, result)
+}
+
+// SendSessionCommand sends a CDP command to a CDP session, such as one created with Target.attachToTarget, and
+// unmarshals its result into result, unless result is nil
+func (d *Debugger) SendSessionCommand(ctx context.Context, sessionID, method string, params, result interface{}) (err error) {
+	// Marshal params
+	ed := &EventDebugger{Method: method, SessionID: sessionID}
+	if params != nil {
+		if ed.Params, err = json.Marshal(params); err != nil {
+			err = fmt.Errorf("marshaling params of %s failed: %w", method, err)
+			return
+		}
+	}
+
+	// Send command
+	var e Event
+	if e, err = synchronousRequest(ctx, d.o, Event{Debugger: ed, Name: EventNameWebContentsCmdDebuggerSendCommand, TargetID: d.o.id}, EventNameWebContentsEventDebuggerSentCommand); err != nil {
+		return
+	}
+
+	// Unmarshal result
+	if result != nil && e.Debugger != nil && len(e.Debugger.Result) > 0 {
+		if err = json.Unmarshal(e.Debugger.Result, result); err != nil {
+			err = fmt.Errorf("unmarshaling result of %s failed: %w", method, err)
+			return
+		}
+	}
+	return
+}
+
+// OnEvent adds a listener to CDP events
+// An empty method means the listener receives all CDP events. CDP events are only sent once the matching domain has
+// been enabled (e.g. with the "Network.enable" command).
+// Listeners are executed one at a time, in the order CDP events are received, which means a listener that blocks
+// delays the following events.
+func (d *Debugger) OnEvent(method string, fn func(e DebuggerEvent) (deleteListener bool)) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.id++
+	d.listeners = append(d.listeners, debuggerListener{fn: fn, id: d.id, method: method})
+}
+
+// enqueue queues a CDP event and makes sure queued events are being delivered
+// It's executed synchronously by the dispatcher, which is why it must not block.
+func (d *Debugger) enqueue(e Event) {
+	// No event
+	if e.Debugger == nil {
+		return
+	}
+
+	// Queue
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.q = append(d.q, DebuggerEvent{
+		Method:    e.Debugger.Method,
+		Params:    e.Debugger.Params,
+		SessionID: e.Debugger.SessionID,
+	})
+
+	// Deliver
+	if !d.running {
+		d.running = true
+		go d.deliver()
+	}
+}
+
+// deliver delivers queued CDP events to listeners until the queue is empty
+func (d *Debugger) deliver() {
+	for {
+		// Get next event
+		d.m.Lock()
+		if len(d.q) == 0 {
+			d.running = false
+			d.m.Unlock()
+			return
+		}
+		e := d.q[0]
+		d.q = d.q[1:]
+		ls := append([]debuggerListener{}, d.listeners...)
+		d.m.Unlock()
+
+		// Execute listeners
+		for _, l := range ls {
+			if l.method != "" && l.method != e.Method {
+				continue
+			}
+			if l.fn(e) {
+				d.delListener(l.id)
+			}
+		}
+	}
+}
+
+// delListener deletes a listener
+func (d *Debugger) delListener(id int) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	for idx, l := range d.listeners {
+		if l.id == id {
+			d.listeners = append(d.listeners[:idx:idx], d.listeners[idx+1:]...)
+			return
+		}
+	}
+}
+
+// OnDetach adds a listener executed when the debugger is detached, for instance when the dev tools are opened or
+// when the web contents are closed
+func (d *Debugger) OnDetach(fn func(reason string)) {
+	d.o.On(EventNameWebContentsEventDebuggerDetached, func(e Event) (deleteListener bool) {
+		var reason string
+		if e.Debugger != nil {
+			reason = e.Debugger.Reason
+		}
+		fn(reason)
+		return
+	})
+}
@@ -0,0 +1,92 @@
+package astilectron
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugger(t *testing.T) {
+	// Init
+	a, err := New(nil, Options{})
+	assert.NoError(t, err)
+	defer a.Close()
+	wrt := &mockedWriter{}
+	a.writer = newWriter(wrt, &logger{})
+	w, err := a.NewWindow("http://test.com", &WindowOptions{})
+	assert.NoError(t, err)
+	d := w.Debugger()
+	b, err := a.NewBrowserView("", &WindowOptions{}, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, b.Debugger())
+
+	// Attach
+	testObjectRequest(t, func() error { return d.Attach("1.3") }, w.object, wrt, "{\"name\":\""+EventNameWebContentsCmdDebuggerAttach+"\",\"targetID\":\""+w.id+"\",\"callbackId\":\"4\",\"debugger\":{\"version\":\"1.3\"}}\n", EventNameWebContentsEventDebuggerAttached, Event{})
+	assert.True(t, d.IsAttached())
+
+	// Send command
+	var r struct {
+		Result struct {
+			Value int `json:"value"`
+		} `json:"result"`
+	}
+	testObjectRequest(t, func() error {
+		return d.SendCommand(context.Background(), "Runtime.evaluate", map[string]interface{}{"expression": "1+1"}, &r)
+	}, w.object, wrt, "{\"name\":\""+EventNameWebContentsCmdDebuggerSendCommand+"\",\"targetID\":\""+w.id+"\",\"callbackId\":\"5\",\"debugger\":{\"method\":\"Runtime.evaluate\",\"params\":{\"expression\":\"1+1\"}}}\n", EventNameWebContentsEventDebuggerSentCommand, Event{Debugger: &EventDebugger{Result: json.RawMessage("{\"result\":{\"type\":\"number\",\"value\":2}}")}})
+	assert.Equal(t, 2, r.Result.Value)
+
+	// Command failure
+	wrt.fn = func() {
+		var e Event
+		json.Unmarshal([]byte(wrt.w[len(wrt.w)-1]), &e)
+		w.d.dispatch(Event{CallbackID: e.CallbackID, Error: "'Unknown.method' wasn't found", Name: EventNameWebContentsEventDebuggerSentCommand, TargetID: w.id})
+	}
+	assert.EqualError(t, d.SendCommand(context.Background(), "Unknown.method", nil, nil), "'Unknown.method' wasn't found")
+	wrt.fn = nil
+
+	// Events
+	var wg sync.WaitGroup
+	var es []DebuggerEvent
+	d.OnEvent("Network.requestWillBeSent", func(e DebuggerEvent) (deleteListener bool) {
+		es = append(es, e)
+		wg.Done()
+		return
+	})
+	wg.Add(1)
+	w.d.dispatch(Event{Debugger: &EventDebugger{Method: "Network.responseReceived"}, Name: EventNameWebContentsEventDebuggerMessage, TargetID: w.id})
+	w.d.dispatch(Event{Debugger: &EventDebugger{Method: "Network.requestWillBeSent", Params: json.RawMessage("{\"requestId\":\"1\"}"), SessionID: "s"}, Name: EventNameWebContentsEventDebuggerMessage, TargetID: w.id})
+	wg.Wait()
+	assert.Equal(t, []DebuggerEvent{{Method: "Network.requestWillBeSent", Params: json.RawMessage("{\"requestId\":\"1\"}"), SessionID: "s"}}, es)
+
+	// Events are received in order
+	var ms []string
+	wg.Add(100)
+	d.OnEvent("", func(e DebuggerEvent) (deleteListener bool) {
+		ms = append(ms, e.Method)
+		wg.Done()
+		return
+	})
+	var ems []string
+	for idx := 0; idx < 100; idx++ {
+		ems = append(ems, "Console.messageAdded"+strconv.Itoa(idx))
+		w.d.dispatch(Event{Debugger: &EventDebugger{Method: ems[idx]}, Name: EventNameWebContentsEventDebuggerMessage, TargetID: w.id})
+	}
+	wg.Wait()
+	assert.Equal(t, ems, ms)
+
+	// Detach
+	var reason string
+	wg.Add(1)
+	d.OnDetach(func(r string) {
+		reason = r
+		wg.Done()
+	})
+	w.d.dispatch(Event{Debugger: &EventDebugger{Reason: "target closed"}, Name: EventNameWebContentsEventDebuggerDetached, TargetID: w.id})
+	wg.Wait()
+	assert.Equal(t, "target closed", reason)
+	testEventually(t, func() bool { return !d.IsAttached() })
+}
@@ -18,15 +18,36 @@ type dispatcher struct {
 	// It means it doesn't store listeners in order
 	l map[string]map[string]map[int]Listener
 	m sync.Mutex
+	// Indexed by target ID then by event name
+	// Ordered handlers are executed synchronously, in the order events are dispatched, and therefore must not block
+	o map[string]map[string]func(e Event)
 }
 
 // newDispatcher creates a new dispatcher
 func newDispatcher() *dispatcher {
 	return &dispatcher{
 		l: make(map[string]map[string]map[int]Listener),
+		o: make(map[string]map[string]func(e Event)),
 	}
 }
 
+// setOrderedHandler sets the ordered handler of an event
+func (d *dispatcher) setOrderedHandler(targetID, eventName string, fn func(e Event)) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if _, ok := d.o[targetID]; !ok {
+		d.o[targetID] = make(map[string]func(e Event))
+	}
+	d.o[targetID][eventName] = fn
+}
+
+// delOrderedHandlers deletes the ordered handlers of a target
+func (d *dispatcher) delOrderedHandlers(targetID string) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	delete(d.o, targetID)
+}
+
 // addListener adds a listener
 func (d *dispatcher) addListener(targetID, eventName string, l Listener) {
 	d.m.Lock()
@@ -56,6 +77,14 @@ func (d *dispatcher) delListener(targetID, eventName string, id int) {
 
 // Dispatch dispatches an event
 func (d *dispatcher) dispatch(e Event) {
+	// Execute the ordered handler
+	d.m.Lock()
+	fn := d.o[e.TargetID][e.Name]
+	d.m.Unlock()
+	if fn != nil {
+		fn(e)
+	}
+
 	// needed so dispatches of events triggered in the listeners can be received without blocking
 	go func() {
 		for id, l := range d.listeners(e.TargetID, e.Name) {
@@ -27,6 +27,33 @@ func (p *displayPool) all() (ds []*Display) {
 	return
 }
 
+// get returns the display with a specific ID
+func (p *displayPool) get(id int64) *Display {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.d[id]
+}
+
+// matching returns the display that overlaps the most with a rectangle
+func (p *displayPool) matching(r Rectangle) (d *Display) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	var max int
+	for _, v := range p.d {
+		if v.o.Bounds == nil {
+			continue
+		}
+		b := v.Bounds()
+		w := minInt(r.X+r.Width, b.X+b.Width) - maxInt(r.X, b.X)
+		h := minInt(r.Y+r.Height, b.Y+b.Height) - maxInt(r.Y, b.Y)
+		if w > 0 && h > 0 && w*h > max {
+			d = v
+			max = w * h
+		}
+	}
+	return
+}
+
 // primary returns the primary display
 // It defaults to the last display
 func (p *displayPool) primary() (d *Display) {
@@ -64,3 +91,17 @@ func (p *displayPool) update(e *EventDisplays) {
 		}
 	}
 }
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
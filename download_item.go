@@ -0,0 +1,306 @@
+package astilectron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Download item event names
+const (
+	EventNameDownloadItemCmdCancel            = "download.item.cmd.cancel"
+	EventNameDownloadItemCmdPause             = "download.item.cmd.pause"
+	EventNameDownloadItemCmdResume            = "download.item.cmd.resume"
+	EventNameDownloadItemEventCancelled       = "download.item.event.cancelled"
+	EventNameDownloadItemEventDone            = "download.item.event.done"
+	EventNameDownloadItemEventPaused          = "download.item.event.paused"
+	EventNameDownloadItemEventResumed         = "download.item.event.resumed"
+	EventNameDownloadItemEventUpdated         = "download.item.event.updated"
+	EventNameSessionEventWillDownloadCallback = "session.event.will.download.callback"
+)
+
+// Download item states
+const (
+	DownloadItemStateCancelled   = "cancelled"
+	DownloadItemStateCompleted   = "completed"
+	DownloadItemStateInterrupted = "interrupted"
+	DownloadItemStateProgressing = "progressing"
+)
+
+// DownloadItem represents a download item
+// Its attributes are kept up to date with the download.item.event.updated and download.item.event.done events, in the
+// order they were sent by Electron, on which listeners can be added as well. Updates received once the download item
+// is done are ignored.
+// https://github.com/electron/electron/blob/v11.4.3/docs/api/download-item.md
+type DownloadItem struct {
+	*object
+	cancelled bool
+	deciding  bool
+	done      bool
+	m         sync.Mutex // Locks cancelled, deciding, done, o and savePath
+	o         EventDownloadItem
+	s         *Session
+	savePath  string
+}
+
+// newDownloadItem creates a new download item
+func newDownloadItem(ctx context.Context, s *Session, o EventDownloadItem) (d *DownloadItem) {
+	d = &DownloadItem{
+		o: o,
+		s: s,
+	}
+	d.object = newObject(ctx, s.d, s.i, s.w, s.i.new())
+	if d.o.State == "" {
+		d.o.State = DownloadItemStateProgressing
+	}
+
+	// Keep the download item up to date
+	d.d.setOrderedHandler(d.id, EventNameDownloadItemEventUpdated, d.update)
+	d.d.setOrderedHandler(d.id, EventNameDownloadItemEventDone, func(e Event) {
+		d.update(e)
+		d.m.Lock()
+		d.done = true
+		d.m.Unlock()
+		d.d.delOrderedHandlers(d.id)
+		d.s.delDownload(d)
+		d.cancel()
+	})
+	return
+}
+
+// update updates the download item based on an event
+// Events may only contain the attributes that have changed, therefore only attributes that are set are updated.
+func (d *DownloadItem) update(e Event) {
+	if e.DownloadItem == nil {
+		return
+	}
+	d.m.Lock()
+	defer d.m.Unlock()
+	if d.done {
+		return
+	}
+	i := e.DownloadItem
+	if i.CanResume != nil {
+		d.o.CanResume = i.CanResume
+	}
+	if i.Filename != "" {
+		d.o.Filename = i.Filename
+	}
+	if i.IsPaused != nil {
+		d.o.IsPaused = i.IsPaused
+	}
+	if i.MimeType != "" {
+		d.o.MimeType = i.MimeType
+	}
+	if i.ReceivedBytes > 0 {
+		d.o.ReceivedBytes = i.ReceivedBytes
+	}
+	if i.SavePath != "" {
+		d.o.SavePath = i.SavePath
+	}
+	if i.StartTime > 0 {
+		d.o.StartTime = i.StartTime
+	}
+	if i.State != "" {
+		d.o.State = i.State
+	}
+	if i.TotalBytes > 0 {
+		d.o.TotalBytes = i.TotalBytes
+	}
+	if i.URL != "" {
+		d.o.URL = i.URL
+	}
+}
+
+// CanResume returns whether the download item can be resumed
+func (d *DownloadItem) CanResume() bool {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return d.o.CanResume != nil && *d.o.CanResume
+}
+
+// Filename returns the download item's file name
+func (d *DownloadItem) Filename() string {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return d.o.Filename
+}
+
+// IsPaused returns whether the download item is paused
+func (d *DownloadItem) IsPaused() bool {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return d.o.IsPaused != nil && *d.o.IsPaused
+}
+
+// MimeType returns the download item's mime type
+func (d *DownloadItem) MimeType() string {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return d.o.MimeType
+}
+
+// ReceivedBytes returns the number of bytes the download item has received
+func (d *DownloadItem) ReceivedBytes() int64 {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return d.o.ReceivedBytes
+}
+
+// SavePath returns the download item's save path
+func (d *DownloadItem) SavePath() string {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if d.deciding && d.savePath != "" {
+		return d.savePath
+	}
+	return d.o.SavePath
+}
+
+// State returns the download item's state
+func (d *DownloadItem) State() string {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return d.o.State
+}
+
+// TotalBytes returns the download item's total size in bytes, or 0 if it's unknown
+func (d *DownloadItem) TotalBytes() int64 {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return d.o.TotalBytes
+}
+
+// URL returns the download item's url
+func (d *DownloadItem) URL() string {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return d.o.URL
+}
+
+// SetSavePath sets the download item's save path, which prevents Electron from prompting the user
+// It can only be called in the OnWillDownload callback
+func (d *DownloadItem) SetSavePath(path string) (err error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if !d.deciding {
+		err = errors.New("the save path can only be set in the OnWillDownload callback")
+		return
+	}
+	d.savePath = path
+	return
+}
+
+// Cancel cancels the download item
+// When called in the OnWillDownload callback, the download doesn't start at all
+func (d *DownloadItem) Cancel() (err error) {
+	// Download is being decided
+	d.m.Lock()
+	if d.deciding {
+		d.cancelled = true
+		d.m.Unlock()
+		return
+	}
+	d.m.Unlock()
+
+	// Send cmd
+	if err = d.ctx.Err(); err != nil {
+		return
+	}
+	_, err = synchronousEvent(d.ctx, d, d.w, Event{Name: EventNameDownloadItemCmdCancel, TargetID: d.id}, EventNameDownloadItemEventCancelled)
+	return
+}
+
+// Pause pauses the download item
+func (d *DownloadItem) Pause() (err error) {
+	if err = d.ctx.Err(); err != nil {
+		return
+	}
+	_, err = synchronousEvent(d.ctx, d, d.w, Event{Name: EventNameDownloadItemCmdPause, TargetID: d.id}, EventNameDownloadItemEventPaused)
+	return
+}
+
+// Resume resumes the download item
+func (d *DownloadItem) Resume() (err error) {
+	if err = d.ctx.Err(); err != nil {
+		return
+	}
+	_, err = synchronousEvent(d.ctx, d, d.w, Event{Name: EventNameDownloadItemCmdResume, TargetID: d.id}, EventNameDownloadItemEventResumed)
+	return
+}
+
+// OnWillDownload executes a callback when the session is about to download an item
+// The callback can set the item's save path or cancel it. Many callbacks can be added, in which case they're executed in
+// the order they were added.
+func (s *Session) OnWillDownload(fn func(d *DownloadItem)) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.willDownload = append(s.willDownload, fn)
+}
+
+// Downloads returns the session's active downloads, in the order they started
+func (s *Session) Downloads() (ds []*DownloadItem) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	ds = make([]*DownloadItem, len(s.downloads))
+	copy(ds, s.downloads)
+	return
+}
+
+// handleWillDownload creates the download item, lets the callbacks decide what to do with it and sends the decision
+// back to Electron
+func (s *Session) handleWillDownload(e Event) {
+	// Create download item
+	var o EventDownloadItem
+	if e.DownloadItem != nil {
+		o = *e.DownloadItem
+	}
+	d := newDownloadItem(s.ctx, s, o)
+
+	// Execute callbacks
+	s.m.Lock()
+	fns := make([]func(d *DownloadItem), len(s.willDownload))
+	copy(fns, s.willDownload)
+	s.m.Unlock()
+	d.m.Lock()
+	d.deciding = true
+	d.m.Unlock()
+	for _, fn := range fns {
+		fn(d)
+	}
+	d.m.Lock()
+	d.deciding = false
+	cancel, savePath := d.cancelled, d.savePath
+	if savePath != "" {
+		d.o.SavePath = savePath
+	}
+	d.m.Unlock()
+
+	// Add download
+	if !cancel {
+		s.m.Lock()
+		s.downloads = append(s.downloads, d)
+		s.m.Unlock()
+	} else {
+		d.d.delOrderedHandlers(d.id)
+		d.cancel()
+	}
+
+	// Send decision back
+	if err := s.w.write(Event{CallbackID: e.CallbackID, Cancel: &cancel, DownloadItem: &EventDownloadItem{ID: d.id, SavePath: savePath}, Name: EventNameSessionEventWillDownloadCallback, TargetID: s.id}); err != nil {
+		s.l.Error(fmt.Errorf("writing %s event failed: %w", EventNameSessionEventWillDownloadCallback, err))
+	}
+}
+
+// delDownload removes a download from the session's active downloads
+func (s *Session) delDownload(d *DownloadItem) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for idx, v := range s.downloads {
+		if v == d {
+			s.downloads = append(s.downloads[:idx], s.downloads[idx+1:]...)
+			return
+		}
+	}
+}
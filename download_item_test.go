@@ -0,0 +1,77 @@
+package astilectron
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/asticode/go-astikit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadItem(t *testing.T) {
+	// Init
+	d := newDispatcher()
+	i := newIdentifier()
+	wrt := &mockedWriter{wg: &sync.WaitGroup{}}
+	w := newWriter(wrt, &logger{})
+	s := newSession(context.Background(), &logger{}, d, i, w)
+	var di *DownloadItem
+	s.OnWillDownload(func(d *DownloadItem) {
+		if d.Filename() != "test.zip" {
+			return
+		}
+		di = d
+		assert.Equal(t, "/tmp/test.zip", d.SavePath())
+		assert.NoError(t, d.SetSavePath("/tmp/downloads/test.zip"))
+	})
+	s.OnWillDownload(func(d *DownloadItem) {
+		if d.Filename() == "virus.exe" {
+			assert.NoError(t, d.Cancel())
+		}
+	})
+
+	// Will download
+	wrt.wg.Add(1)
+	d.dispatch(Event{CallbackID: "1", DownloadItem: &EventDownloadItem{Filename: "test.zip", SavePath: "/tmp/test.zip", TotalBytes: 100, URL: "https://example.com/test.zip"}, Name: EventNameSessionEventWillDownload, TargetID: s.id})
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"session.event.will.download.callback\",\"targetID\":\"1\",\"cancel\":false,\"callbackId\":\"1\",\"downloadItem\":{\"id\":\"2\",\"savePath\":\"/tmp/downloads/test.zip\"}}\n"}, wrt.w)
+	assert.Equal(t, []*DownloadItem{di}, s.Downloads())
+	assert.Equal(t, "/tmp/downloads/test.zip", di.SavePath())
+	assert.Equal(t, DownloadItemStateProgressing, di.State())
+	assert.Error(t, di.SetSavePath("/tmp/test.zip"))
+
+	// Cancelled in the callback
+	wrt.w = []string{}
+	wrt.wg.Add(1)
+	d.dispatch(Event{CallbackID: "2", DownloadItem: &EventDownloadItem{Filename: "virus.exe"}, Name: EventNameSessionEventWillDownload, TargetID: s.id})
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"session.event.will.download.callback\",\"targetID\":\"1\",\"cancel\":true,\"callbackId\":\"2\",\"downloadItem\":{\"id\":\"3\"}}\n"}, wrt.w)
+	assert.Len(t, s.Downloads(), 1)
+
+	// Commands
+	wrt.wg = nil
+	testObjectAction(t, func() error { return di.Pause() }, di.object, wrt, "{\"name\":\""+EventNameDownloadItemCmdPause+"\",\"targetID\":\"2\"}\n", EventNameDownloadItemEventPaused)
+	testObjectAction(t, func() error { return di.Resume() }, di.object, wrt, "{\"name\":\""+EventNameDownloadItemCmdResume+"\",\"targetID\":\"2\"}\n", EventNameDownloadItemEventResumed)
+	testObjectAction(t, func() error { return di.Cancel() }, di.object, wrt, "{\"name\":\""+EventNameDownloadItemCmdCancel+"\",\"targetID\":\"2\"}\n", EventNameDownloadItemEventCancelled)
+
+	// Updated
+	d.dispatch(Event{DownloadItem: &EventDownloadItem{CanResume: astikit.BoolPtr(true), IsPaused: astikit.BoolPtr(true), ReceivedBytes: 50}, Name: EventNameDownloadItemEventUpdated, TargetID: di.id})
+	assert.Equal(t, int64(50), di.ReceivedBytes())
+	assert.True(t, di.CanResume())
+	assert.Equal(t, "test.zip", di.Filename())
+	assert.True(t, di.IsPaused())
+	assert.Equal(t, DownloadItemStateProgressing, di.State())
+	assert.Equal(t, int64(100), di.TotalBytes())
+
+	// Done
+	d.dispatch(Event{DownloadItem: &EventDownloadItem{ReceivedBytes: 100, SavePath: "/tmp/downloads/test.zip", State: DownloadItemStateCompleted, TotalBytes: 100}, Name: EventNameDownloadItemEventDone, TargetID: di.id})
+	assert.Empty(t, s.Downloads())
+	assert.Equal(t, DownloadItemStateCompleted, di.State())
+
+	// Updates are ignored once done
+	d.dispatch(Event{DownloadItem: &EventDownloadItem{ReceivedBytes: 75, State: DownloadItemStateProgressing}, Name: EventNameDownloadItemEventUpdated, TargetID: di.id})
+	assert.Equal(t, DownloadItemStateCompleted, di.State())
+	assert.Equal(t, int64(100), di.ReceivedBytes())
+	assert.Error(t, di.Pause())
+}
@@ -20,29 +20,42 @@ type Event struct {
 	// This is a list of all possible payloads.
 	// A choice was made not to use interfaces since it's a pain in the ass asserting each an every payload afterwards
 	// We use pointers so that omitempty works
-	AcceptLanguages string            `json:"acceptLanguages,omitempty"`
-	AuthInfo        *EventAuthInfo    `json:"authInfo,omitempty"`
-	Badge           string            `json:"badge,omitempty"`
-	BounceType      string            `json:"bounceType,omitempty"`
-	Bounds          *RectangleOptions `json:"bounds,omitempty"`
-	BrowserViewID   string            `json:"browserViewID,omitempty"`
-	Cancel          *bool             `json:"cancel,omitempty"`
-	CallbackID      string            `json:"callbackId,omitempty"`
-	Color           string            `json:"color,omitempty"`
-	Code            string            `json:"code,omitempty"`
-	//todo: can only be a string now?
-	CodeResult string          `json:"codeResult,omitempty"`
-	Cookies    []SessionCookie `json:"cookies,omitempty"`
+	AcceptLanguages         string                    `json:"acceptLanguages,omitempty"`
+	Allowed                 *bool                     `json:"allowed,omitempty"`
+	AuthInfo                *EventAuthInfo            `json:"authInfo,omitempty"`
+	Badge                   string                    `json:"badge,omitempty"`
+	BounceType              string                    `json:"bounceType,omitempty"`
+	Bounds                  *RectangleOptions         `json:"bounds,omitempty"`
+	BrowserViewID           string                    `json:"browserViewID,omitempty"`
+	Bytes                   []byte                    `json:"bytes,omitempty"`
+	CacheSize               *int                      `json:"cacheSize,omitempty"`
+	Cancel                  *bool                     `json:"cancel,omitempty"`
+	CertificateError        *CertificateError         `json:"certificateError,omitempty"`
+	CertificateVerify       *CertificateVerifyRequest `json:"certificateVerify,omitempty"`
+	CallbackID              string                    `json:"callbackId,omitempty"`
+	ClearStorageDataOptions *ClearStorageDataOptions  `json:"clearStorageDataOptions,omitempty"`
+	Color                   string                    `json:"color,omitempty"`
+	Code                    string                    `json:"code,omitempty"`
+	// Use Evaluate to get typed results
+	CodeResult   string               `json:"codeResult,omitempty"`
+	CookieChange *SessionCookieChange `json:"cookieChange,omitempty"`
+	CookieFilter *SessionCookieFilter `json:"cookieFilter,omitempty"`
+	Cookies      []SessionCookie      `json:"cookies,omitempty"`
 	// https://www.electronjs.org/docs/api/structures/protocol-response
 	Data                  string                 `json:"data,omitempty"`
+	Debugger              *EventDebugger         `json:"debugger,omitempty"`
 	Displays              *EventDisplays         `json:"displays,omitempty"`
+	DownloadItem          *EventDownloadItem     `json:"downloadItem,omitempty"`
 	DialogOptions         *DialogOptions         `json:"dialogOptions,omitempty"`
 	Error                 string                 `json:"error,omitempty"`
 	FilePath              string                 `json:"filePath,omitempty"`
+	Handlers              []string               `json:"handlers,omitempty"` // Names of the commands setting the handlers of web contents being created
+	Headers               map[string][]string    `json:"headers,omitempty"`
 	ID                    *int                   `json:"id,omitempty"`
 	Filter                *FilterOptions         `json:"filter,omitempty"`
 	Image                 string                 `json:"image,omitempty"`
 	Index                 *int                   `json:"index,omitempty"`
+	LoadFailure           *LoadFailure           `json:"loadFailure,omitempty"`
 	Menu                  *EventMenu             `json:"menu,omitempty"`
 	Load                  *Load                  `json:"load,omitempty"`
 	MenuItem              *EventMenuItem         `json:"menuItem,omitempty"`
@@ -51,29 +64,46 @@ type Event struct {
 	MenuPopupOptions      *MenuPopupOptions      `json:"menuPopupOptions,omitempty"`
 	Message               *EventMessage          `json:"message,omitempty"`
 	MimeType              string                 `json:"mimeType,omitempty"`
+	NavigationHistory     *NavigationHistory     `json:"navigationHistory,omitempty"`
 	NotificationOptions   *NotificationOptions   `json:"notificationOptions,omitempty"`
 	Password              string                 `json:"password,omitempty"`
 	Path                  string                 `json:"path,omitempty"`
 	Paths                 []string               `json:"paths,omitempty"`
 	Partition             string                 `json:"partition,omitempty"`
+	Permission            *PermissionRequest     `json:"permission,omitempty"`
+	PermissionCheckPolicy *PermissionCheckPolicy `json:"permissionCheckPolicy,omitempty"`
+	PrintToPDFOptions     *PrintToPDFOptions     `json:"printToPDFOptions,omitempty"`
 	Proxy                 *WindowProxyOptions    `json:"proxy,omitempty"`
 	RedirectURL           string                 `json:"redirectURL,omitempty"`
+	RenderProcessGone     *RenderProcessGone     `json:"renderProcessGone,omitempty"`
 	Reply                 string                 `json:"reply,omitempty"`
 	ResizeOptions         *ResizeOptions         `json:"resizeOptions,omitempty"`
 	Request               *EventRequest          `json:"request,omitempty"`
+	RequestHeaders        map[string]string      `json:"requestHeaders,omitempty"`
+	ResponseHeaders       map[string][]string    `json:"responseHeaders,omitempty"`
 	Scheme                string                 `json:"scheme,omitempty"`
 	SecondInstance        *EventSecondInstance   `json:"secondInstance,omitempty"`
+	Sender                *MessageSender         `json:"sender,omitempty"`
 	SessionID             string                 `json:"sessionId,omitempty"`
 	ShowOpenDialogOptions *ShowOpenDialogOptions `json:"showOpenDialogOptions,omitempty"`
+	StatusCode            *int                   `json:"statusCode,omitempty"`
+	StatusLine            string                 `json:"statusLine,omitempty"`
 	Supported             *Supported             `json:"supported,omitempty"`
 	TrayOptions           *TrayOptions           `json:"trayOptions,omitempty"`
+	Trusted               *bool                  `json:"trusted,omitempty"`
 	URL                   string                 `json:"url,omitempty"`
 	URLNew                string                 `json:"newUrl,omitempty"`
 	URLOld                string                 `json:"oldUrl,omitempty"`
 	UserAgent             string                 `json:"userAgent,omitempty"`
+	VerificationResult    *int                   `json:"verificationResult,omitempty"`
 	Username              string                 `json:"username,omitempty"`
+	WebRequest            *WebRequestDetails     `json:"webRequest,omitempty"`
 	WindowID              string                 `json:"windowId,omitempty"`
+	WindowOpen            *WindowOpenDetails     `json:"windowOpen,omitempty"`
+	WindowOpenAction      string                 `json:"windowOpenAction,omitempty"`
 	WindowOptions         *WindowOptions         `json:"windowOptions,omitempty"`
+	WindowState           *EventWindowState      `json:"windowState,omitempty"`
+	WorldID               *int                   `json:"worldId,omitempty"`
 }
 
 // EventAuthInfo represents an event auth info
@@ -85,6 +115,17 @@ type EventAuthInfo struct {
 	Scheme  string `json:"scheme,omitempty"`
 }
 
+// EventDebugger represents an event debugger
+// Depending on the event, it contains a CDP command, a CDP command result, a CDP event or a detach reason
+type EventDebugger struct {
+	Method    string          `json:"method,omitempty"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	Reason    string          `json:"reason,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	SessionID string          `json:"sessionId,omitempty"`
+	Version   string          `json:"version,omitempty"`
+}
+
 // EventDisplays represents events displays
 type EventDisplays struct {
 	All     []*DisplayOptions `json:"all,omitempty"`
@@ -95,9 +136,35 @@ type FilterOptions struct {
 	Urls []string `json:"urls,omitempty"`
 }
 
+// EventDownloadItem represents an event download item
+type EventDownloadItem struct {
+	CanResume     *bool   `json:"canResume,omitempty"`
+	Filename      string  `json:"filename,omitempty"`
+	ID            string  `json:"id,omitempty"`
+	IsPaused      *bool   `json:"isPaused,omitempty"`
+	MimeType      string  `json:"mimeType,omitempty"`
+	ReceivedBytes int64   `json:"receivedBytes,omitempty"`
+	SavePath      string  `json:"savePath,omitempty"`
+	StartTime     float64 `json:"startTime,omitempty"`
+	State         string  `json:"state,omitempty"`
+	TotalBytes    int64   `json:"totalBytes,omitempty"`
+	URL           string  `json:"url,omitempty"`
+}
+
 // EventMessage represents an event message
 type EventMessage struct {
-	i interface{}
+	i      interface{}
+	sender *MessageSender
+}
+
+// MessageSender represents the frame a message has been sent from
+// BrowserViewID is only set when the message has been sent from a browser view
+type MessageSender struct {
+	BrowserViewID string `json:"browserViewId,omitempty"`
+	FrameID       int    `json:"frameId"`
+	FrameURL      string `json:"frameUrl,omitempty"`
+	IsMainFrame   bool   `json:"isMainFrame,omitempty"`
+	Origin        string `json:"origin,omitempty"`
 }
 
 // newEventMessage creates a new event message
@@ -110,6 +177,11 @@ func (p *EventMessage) MarshalJSON() ([]byte, error) {
 	return json.Marshal(p.i)
 }
 
+// Sender returns the frame the message has been sent from, if known
+func (p *EventMessage) Sender() *MessageSender {
+	return p.sender
+}
+
 // Unmarshal unmarshals the payload into the given interface
 func (p *EventMessage) Unmarshal(i interface{}) error {
 	if b, ok := p.i.([]byte); ok {
@@ -158,6 +230,8 @@ type UploadData struct {
 
 // EventRequest represents an event request
 type EventRequest struct {
+	Body       []byte             `json:"body,omitempty"`
+	Headers    map[string]string  `json:"headers,omitempty"`
 	Method     string             `json:"method,omitempty"`
 	Referrer   string             `json:"referrer,omitempty"`
 	URL        string             `json:"url,omitempty"`
@@ -176,3 +250,14 @@ type EventSubMenu struct {
 	Items  []*EventMenuItem `json:"items,omitempty"`
 	RootID string           `json:"rootId"`
 }
+
+// EventWindowState represents the state of a window as reported by Electron alongside window events
+type EventWindowState struct {
+	Bounds       *RectangleOptions `json:"bounds,omitempty"`
+	IsFocused    *bool             `json:"isFocused,omitempty"`
+	IsFullScreen *bool             `json:"isFullScreen,omitempty"`
+	IsMaximized  *bool             `json:"isMaximized,omitempty"`
+	IsMinimized  *bool             `json:"isMinimized,omitempty"`
+	IsVisible    *bool             `json:"isVisible,omitempty"`
+	Title        *string           `json:"title,omitempty"`
+}
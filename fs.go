@@ -0,0 +1,185 @@
+package astilectron
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astikit"
+)
+
+// FSScheme is the privileged scheme used to serve windows created with an fs.FS
+const FSScheme = "astilectron-fs"
+
+// fsIndex is the file served when a directory or an unknown page is requested
+const fsIndex = "index.html"
+
+// fsHandler represents an http.Handler serving an fs.FS
+type fsHandler struct {
+	e    map[string]string // ETags indexed by path
+	fsys fs.FS
+	m    sync.Mutex // Locks e
+}
+
+// NewFSHandler creates an http.Handler serving an fs.FS such as an embed.FS
+// MIME types are inferred from file extensions, requests for unknown pages fall back to index.html so that single
+// page applications can handle their own routing, and ETags are provided so that unchanged files are not sent twice.
+func NewFSHandler(fsys fs.FS) http.Handler {
+	return &fsHandler{
+		e:    make(map[string]string),
+		fsys: fsys,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *fsHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	// Check method
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		rw.Header().Set("Allow", http.MethodGet+", "+http.MethodHead)
+		http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get name
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" {
+		name = fsIndex
+	}
+
+	// Read file
+	b, name, err := h.read(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && h.isPage(name, r) {
+			b, name, err = h.read(fsIndex)
+		}
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				http.NotFound(rw, r)
+			} else {
+				http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+
+	// Set headers
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		rw.Header().Set("Content-Type", t)
+	} else {
+		rw.Header().Set("Content-Type", http.DetectContentType(b))
+	}
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("ETag", h.etag(name, b))
+
+	// Serve content, which handles If-None-Match, range and HEAD requests
+	http.ServeContent(rw, r, name, time.Time{}, bytes.NewReader(b))
+}
+
+// read reads a file, or the index of a directory, and returns its content as well as the name of the file that has
+// actually been read
+func (h *fsHandler) read(name string) (b []byte, n string, err error) {
+	// Stat
+	n = name
+	var fi fs.FileInfo
+	if fi, err = fs.Stat(h.fsys, n); err != nil {
+		return
+	}
+
+	// Directory
+	if fi.IsDir() {
+		n = path.Join(n, fsIndex)
+	}
+
+	// Read
+	b, err = fs.ReadFile(h.fsys, n)
+	return
+}
+
+// isPage checks whether a request targets a page rather than an asset
+func (h *fsHandler) isPage(name string, r *http.Request) bool {
+	return path.Ext(name) == "" || strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// etag returns the ETag of a file
+// Files of an fs.FS are not supposed to change which allows caching ETags
+func (h *fsHandler) etag(name string, b []byte) string {
+	h.m.Lock()
+	defer h.m.Unlock()
+	e, ok := h.e[name]
+	if !ok {
+		s := sha256.Sum256(b)
+		e = "\"" + hex.EncodeToString(s[:16]) + "\""
+		h.e[name] = e
+	}
+	return e
+}
+
+// fsRouter represents an http.Handler routing requests to the fs.FS handler of a window based on their host
+type fsRouter struct {
+	h map[string]http.Handler // Indexed by host
+	m sync.Mutex              // Locks h
+}
+
+// newFSRouter creates a new fs router
+func newFSRouter() *fsRouter {
+	return &fsRouter{h: make(map[string]http.Handler)}
+}
+
+// add adds an fs.FS served on a specific host
+func (r *fsRouter) add(host string, fsys fs.FS) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.h[host] = NewFSHandler(fsys)
+}
+
+// del removes the fs.FS served on a specific host
+func (r *fsRouter) del(host string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	delete(r.h, host)
+}
+
+// ServeHTTP implements the http.Handler interface
+func (r *fsRouter) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	r.m.Lock()
+	h, ok := r.h[req.URL.Host]
+	r.m.Unlock()
+	if !ok {
+		http.NotFound(rw, req)
+		return
+	}
+	h.ServeHTTP(rw, req)
+}
+
+// fsHost returns the host an object's fs.FS is served on
+// Chromium parses numeric hosts of standard schemes as IPv4 addresses, hence the prefix
+func fsHost(id string) string {
+	return "window-" + id
+}
+
+// fsURL returns the url of a path of an object's fs.FS
+func fsURL(id, p string) string {
+	return fmt.Sprintf("%s://%s/%s", FSScheme, fsHost(id), strings.TrimPrefix(path.Clean("/"+p), "/"))
+}
+
+// fsProtocolScheme returns the privileged scheme used to serve fs.FS
+func fsProtocolScheme() ProtocolScheme {
+	return ProtocolScheme{
+		Privileges: &ProtocolSchemePrivileges{
+			CORSEnabled:     astikit.BoolPtr(true),
+			Secure:          astikit.BoolPtr(true),
+			Standard:        astikit.BoolPtr(true),
+			SupportFetchAPI: astikit.BoolPtr(true),
+		},
+		Scheme: FSScheme,
+	}
+}
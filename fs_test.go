@@ -0,0 +1,83 @@
+package astilectron
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFSHandler(t *testing.T) {
+	h := NewFSHandler(fstest.MapFS{
+		"index.html":     &fstest.MapFile{Data: []byte("<html>index</html>")},
+		"js/app.js":      &fstest.MapFile{Data: []byte("console.log('app')")},
+		"sub/index.html": &fstest.MapFile{Data: []byte("<html>sub</html>")},
+	})
+	serve := func(method, url string, headers map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, url, nil)
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, r)
+		return rw
+	}
+
+	// Index
+	rw := serve(http.MethodGet, "app://host/", nil)
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "<html>index</html>", rw.Body.String())
+	assert.Equal(t, "text/html; charset=utf-8", rw.Header().Get("Content-Type"))
+	etag := rw.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	// Asset
+	rw = serve(http.MethodGet, "app://host/js/app.js", nil)
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "console.log('app')", rw.Body.String())
+	assert.Contains(t, rw.Header().Get("Content-Type"), "javascript")
+
+	// Directory
+	rw = serve(http.MethodGet, "app://host/sub", nil)
+	assert.Equal(t, "<html>sub</html>", rw.Body.String())
+
+	// SPA fallback
+	rw = serve(http.MethodGet, "app://host/some/route", nil)
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "<html>index</html>", rw.Body.String())
+	rw = serve(http.MethodGet, "app://host/some/route.html", map[string]string{"Accept": "text/html,*/*"})
+	assert.Equal(t, "<html>index</html>", rw.Body.String())
+
+	// Not found
+	rw = serve(http.MethodGet, "app://host/js/unknown.js", nil)
+	assert.Equal(t, http.StatusNotFound, rw.Code)
+
+	// ETag
+	rw = serve(http.MethodGet, "app://host/index.html", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, rw.Code)
+	assert.Empty(t, rw.Body.String())
+
+	// Method
+	rw = serve(http.MethodPost, "app://host/", nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+}
+
+func TestFSRouter(t *testing.T) {
+	r := newFSRouter()
+	r.add("window-1", fstest.MapFS{"index.html": &fstest.MapFile{Data: []byte("1")}})
+	rw := httptest.NewRecorder()
+	r.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, FSScheme+"://window-1/", nil))
+	assert.Equal(t, "1", rw.Body.String())
+	r.del("window-1")
+	rw = httptest.NewRecorder()
+	r.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, FSScheme+"://window-1/", nil))
+	assert.Equal(t, http.StatusNotFound, rw.Code)
+}
+
+func TestFSURL(t *testing.T) {
+	assert.Equal(t, FSScheme+"://window-1/", fsURL("1", ""))
+	assert.Equal(t, FSScheme+"://window-1/index.html", fsURL("1", "index.html"))
+	assert.Equal(t, FSScheme+"://window-1/index.html", fsURL("1", "/../index.html"))
+}
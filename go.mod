@@ -1,6 +1,6 @@
 module github.com/Nebulabots/go-astilectron
 
-go 1.13
+go 1.16
 
 require (
 	github.com/asticode/go-astikit v0.15.0
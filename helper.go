@@ -3,6 +3,7 @@ package astilectron
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -149,3 +150,65 @@ func synchronousEvent(ctx context.Context, l listenable, w *writer, i Event, eve
 		return
 	}, eventNameDone)
 }
+
+// synchronousRequest sends an event tagged with a unique callback id, blocks until it has received the done event
+// carrying the same callback id or either the context or the object's context has been cancelled, and returns the
+// corresponding event
+// Contrary to synchronousEvent, concurrent requests can't receive each other's done events and both a cancelled
+// context and an error reported by Electron are returned as errors.
+func synchronousRequest(ctx context.Context, o *object, i Event, eventNameDone string) (e Event, err error) {
+	// Check contexts
+	if err = o.ctx.Err(); err != nil {
+		return
+	}
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	// Merge contexts
+	octx := o.ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-octx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// Listen to the done event
+	i.CallbackID = o.i.new()
+	ch := make(chan Event, 1)
+	o.On(eventNameDone, func(r Event) (deleteListener bool) {
+		if ctx.Err() != nil {
+			return true
+		}
+		if r.CallbackID != i.CallbackID {
+			return
+		}
+		ch <- r
+		return true
+	})
+
+	// Write
+	if err = o.w.write(i); err != nil {
+		err = fmt.Errorf("writing %+v event failed: %w", i, err)
+		return
+	}
+
+	// Wait for the done event
+	select {
+	case e = <-ch:
+	case <-ctx.Done():
+		err = ctx.Err()
+		return
+	}
+
+	// Electron reported an error
+	if e.Error != "" {
+		err = errors.New(e.Error)
+		return
+	}
+	return
+}
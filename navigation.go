@@ -0,0 +1,272 @@
+package astilectron
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/asticode/go-astikit"
+)
+
+// Window navigation event names
+const (
+	EventNameWindowCmdWebContentsGetNavigationHistory    = "window.cmd.web.contents.get.navigation.history"
+	EventNameWindowCmdWebContentsGoBack                  = "window.cmd.web.contents.go.back"
+	EventNameWindowCmdWebContentsGoForward               = "window.cmd.web.contents.go.forward"
+	EventNameWindowCmdWebContentsGoToIndex               = "window.cmd.web.contents.go.to.index"
+	EventNameWindowCmdWebContentsReload                  = "window.cmd.web.contents.reload"
+	EventNameWindowCmdWebContentsReloadIgnoringCache     = "window.cmd.web.contents.reload.ignoring.cache"
+	EventNameWindowCmdWebContentsSetWillNavigateHandler  = "window.cmd.web.contents.set.will.navigate.handler"
+	EventNameWindowCmdWebContentsStop                    = "window.cmd.web.contents.stop"
+	EventNameWindowEventWebContentsDidNavigate           = "window.event.web.contents.did.navigate"
+	EventNameWindowEventWebContentsDidNavigateInPage     = "window.event.web.contents.did.navigate.in.page"
+	EventNameWindowEventWebContentsGotNavigationHistory  = "window.event.web.contents.got.navigation.history"
+	EventNameWindowEventWebContentsReloaded              = "window.event.web.contents.reloaded"
+	EventNameWindowEventWebContentsReloadedIgnoringCache = "window.event.web.contents.reloaded.ignoring.cache"
+	EventNameWindowEventWebContentsStopped               = "window.event.web.contents.stopped"
+	EventNameWindowEventWebContentsWentBack              = "window.event.web.contents.went.back"
+	EventNameWindowEventWebContentsWentForward           = "window.event.web.contents.went.forward"
+	EventNameWindowEventWebContentsWentToIndex           = "window.event.web.contents.went.to.index"
+	EventNameWindowEventWebContentsWillNavigate          = "window.event.web.contents.will.navigate"
+	EventNameWindowEventWebContentsWillNavigateCallback  = "window.event.web.contents.will.navigate.callback"
+)
+
+// Browser view navigation event names
+const (
+	EventNameBrowserViewCmdWebContentsGetNavigationHistory    = "browser.view.cmd.web.contents.get.navigation.history"
+	EventNameBrowserViewCmdWebContentsGoBack                  = "browser.view.cmd.web.contents.go.back"
+	EventNameBrowserViewCmdWebContentsGoForward               = "browser.view.cmd.web.contents.go.forward"
+	EventNameBrowserViewCmdWebContentsGoToIndex               = "browser.view.cmd.web.contents.go.to.index"
+	EventNameBrowserViewCmdWebContentsReload                  = "browser.view.cmd.web.contents.reload"
+	EventNameBrowserViewCmdWebContentsReloadIgnoringCache     = "browser.view.cmd.web.contents.reload.ignoring.cache"
+	EventNameBrowserViewCmdWebContentsSetWillNavigateHandler  = "browser.view.cmd.web.contents.set.will.navigate.handler"
+	EventNameBrowserViewCmdWebContentsStop                    = "browser.view.cmd.web.contents.stop"
+	EventNameBrowserViewEventWebContentsDidNavigate           = "browser.view.event.web.contents.did.navigate"
+	EventNameBrowserViewEventWebContentsDidNavigateInPage     = "browser.view.event.web.contents.did.navigate.in.page"
+	EventNameBrowserViewEventWebContentsGotNavigationHistory  = "browser.view.event.web.contents.got.navigation.history"
+	EventNameBrowserViewEventWebContentsReloaded              = "browser.view.event.web.contents.reloaded"
+	EventNameBrowserViewEventWebContentsReloadedIgnoringCache = "browser.view.event.web.contents.reloaded.ignoring.cache"
+	EventNameBrowserViewEventWebContentsStopped               = "browser.view.event.web.contents.stopped"
+	EventNameBrowserViewEventWebContentsWentBack              = "browser.view.event.web.contents.went.back"
+	EventNameBrowserViewEventWebContentsWentForward           = "browser.view.event.web.contents.went.forward"
+	EventNameBrowserViewEventWebContentsWentToIndex           = "browser.view.event.web.contents.went.to.index"
+	EventNameBrowserViewEventWebContentsWillNavigate          = "browser.view.event.web.contents.will.navigate"
+	EventNameBrowserViewEventWebContentsWillNavigateCallback  = "browser.view.event.web.contents.will.navigate.callback"
+)
+
+// Navigation event names of windows and browser views
+var (
+	browserViewNavigationEventNames = navigationEventNames{
+		getHistory:             EventNameBrowserViewCmdWebContentsGetNavigationHistory,
+		goBack:                 EventNameBrowserViewCmdWebContentsGoBack,
+		goForward:              EventNameBrowserViewCmdWebContentsGoForward,
+		goToIndex:              EventNameBrowserViewCmdWebContentsGoToIndex,
+		gotHistory:             EventNameBrowserViewEventWebContentsGotNavigationHistory,
+		reload:                 EventNameBrowserViewCmdWebContentsReload,
+		reloadIgnoringCache:    EventNameBrowserViewCmdWebContentsReloadIgnoringCache,
+		reloaded:               EventNameBrowserViewEventWebContentsReloaded,
+		reloadedIgnoringCache:  EventNameBrowserViewEventWebContentsReloadedIgnoringCache,
+		setWillNavigateHandler: EventNameBrowserViewCmdWebContentsSetWillNavigateHandler,
+		stop:                   EventNameBrowserViewCmdWebContentsStop,
+		stopped:                EventNameBrowserViewEventWebContentsStopped,
+		wentBack:               EventNameBrowserViewEventWebContentsWentBack,
+		wentForward:            EventNameBrowserViewEventWebContentsWentForward,
+		wentToIndex:            EventNameBrowserViewEventWebContentsWentToIndex,
+		willNavigate:           EventNameBrowserViewEventWebContentsWillNavigate,
+		willNavigateCallback:   EventNameBrowserViewEventWebContentsWillNavigateCallback,
+	}
+	windowNavigationEventNames = navigationEventNames{
+		getHistory:             EventNameWindowCmdWebContentsGetNavigationHistory,
+		goBack:                 EventNameWindowCmdWebContentsGoBack,
+		goForward:              EventNameWindowCmdWebContentsGoForward,
+		goToIndex:              EventNameWindowCmdWebContentsGoToIndex,
+		gotHistory:             EventNameWindowEventWebContentsGotNavigationHistory,
+		reload:                 EventNameWindowCmdWebContentsReload,
+		reloadIgnoringCache:    EventNameWindowCmdWebContentsReloadIgnoringCache,
+		reloaded:               EventNameWindowEventWebContentsReloaded,
+		reloadedIgnoringCache:  EventNameWindowEventWebContentsReloadedIgnoringCache,
+		setWillNavigateHandler: EventNameWindowCmdWebContentsSetWillNavigateHandler,
+		stop:                   EventNameWindowCmdWebContentsStop,
+		stopped:                EventNameWindowEventWebContentsStopped,
+		wentBack:               EventNameWindowEventWebContentsWentBack,
+		wentForward:            EventNameWindowEventWebContentsWentForward,
+		wentToIndex:            EventNameWindowEventWebContentsWentToIndex,
+		willNavigate:           EventNameWindowEventWebContentsWillNavigate,
+		willNavigateCallback:   EventNameWindowEventWebContentsWillNavigateCallback,
+	}
+)
+
+// navigationEventNames represents the event names used by the navigation of a type of web contents
+type navigationEventNames struct {
+	getHistory             string
+	goBack                 string
+	goForward              string
+	goToIndex              string
+	gotHistory             string
+	reload                 string
+	reloadIgnoringCache    string
+	reloaded               string
+	reloadedIgnoringCache  string
+	setWillNavigateHandler string
+	stop                   string
+	stopped                string
+	wentBack               string
+	wentForward            string
+	wentToIndex            string
+	willNavigate           string
+	willNavigateCallback   string
+}
+
+// NavigationHistory represents the navigation history of web contents
+// Index is the index of the current entry
+type NavigationHistory struct {
+	Entries []NavigationEntry `json:"entries"`
+	Index   int               `json:"index"`
+}
+
+// NavigationEntry represents an entry of the navigation history
+type NavigationEntry struct {
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url"`
+}
+
+// CanGoBack returns whether there's an entry before the current one
+func (h NavigationHistory) CanGoBack() bool {
+	return h.Index > 0
+}
+
+// CanGoForward returns whether there's an entry after the current one
+func (h NavigationHistory) CanGoForward() bool {
+	return h.Index < len(h.Entries)-1
+}
+
+// navigation represents the navigation of web contents
+// Electron's will-navigate event can't wait for an asynchronous decision: once at least one will navigate hook has
+// been added, Electron prevents every navigation initiated by the page, waits for GO's decision and, if the
+// navigation is allowed, replays it by loading its url. Replayed navigations are GET requests without referrer, which
+// means POST bodies (e.g. form submissions) and the referrer are lost.
+type navigation struct {
+	created func() bool // Whether the web contents have been created. nil means they always have.
+	fns     []func(url string) (allow bool)
+	l       astikit.SeverityLogger
+	m       sync.Mutex // Locks fns
+	n       navigationEventNames
+	o       *object
+}
+
+// newNavigation creates a new navigation
+func newNavigation(o *object, l astikit.SeverityLogger, n navigationEventNames, created func() bool) *navigation {
+	return &navigation{
+		created: created,
+		l:       l,
+		n:       n,
+		o:       o,
+	}
+}
+
+// hooked returns whether at least one will navigate hook has been added
+func (n *navigation) hooked() bool {
+	n.m.Lock()
+	defer n.m.Unlock()
+	return len(n.fns) > 0
+}
+
+// command sends a navigation command and waits for Electron to have executed it
+func (n *navigation) command(ctx context.Context, e Event, eventNameDone string) (err error) {
+	e.TargetID = n.o.id
+	_, err = synchronousRequest(ctx, n.o, e, eventNameDone)
+	return
+}
+
+func (n *navigation) goBack(ctx context.Context) error {
+	return n.command(ctx, Event{Name: n.n.goBack}, n.n.wentBack)
+}
+
+func (n *navigation) goForward(ctx context.Context) error {
+	return n.command(ctx, Event{Name: n.n.goForward}, n.n.wentForward)
+}
+
+func (n *navigation) goToIndex(ctx context.Context, index int) error {
+	return n.command(ctx, Event{Index: astikit.IntPtr(index), Name: n.n.goToIndex}, n.n.wentToIndex)
+}
+
+func (n *navigation) reload(ctx context.Context) error {
+	return n.command(ctx, Event{Name: n.n.reload}, n.n.reloaded)
+}
+
+func (n *navigation) reloadIgnoringCache(ctx context.Context) error {
+	return n.command(ctx, Event{Name: n.n.reloadIgnoringCache}, n.n.reloadedIgnoringCache)
+}
+
+func (n *navigation) stop(ctx context.Context) error {
+	return n.command(ctx, Event{Name: n.n.stop}, n.n.stopped)
+}
+
+func (n *navigation) history(ctx context.Context) (h NavigationHistory, err error) {
+	var e Event
+	if e, err = synchronousRequest(ctx, n.o, Event{Name: n.n.getHistory, TargetID: n.o.id}, n.n.gotHistory); err != nil {
+		return
+	}
+	if e.NavigationHistory != nil {
+		h = *e.NavigationHistory
+	}
+	return
+}
+
+// onWillNavigate adds a will navigate hook and lets Electron know it should wait for GO's decision before navigating
+func (n *navigation) onWillNavigate(fn func(url string) (allow bool)) {
+	// Add hook
+	n.m.Lock()
+	listening := len(n.fns) > 0
+	n.fns = append(n.fns, fn)
+	n.m.Unlock()
+
+	// Already listening
+	if listening {
+		return
+	}
+
+	// Listen
+	n.o.On(n.n.willNavigate, func(e Event) (deleteListener bool) {
+		n.handleWillNavigate(e)
+		return
+	})
+
+	// Check context
+	if err := n.o.ctx.Err(); err != nil {
+		return
+	}
+
+	// Web contents have not been created yet, in which case the handler is set when they are
+	if n.created != nil && !n.created() {
+		return
+	}
+
+	// Set handler
+	if err := n.o.w.write(Event{Name: n.n.setWillNavigateHandler, TargetID: n.o.id}); err != nil {
+		n.l.Error(fmt.Errorf("writing %s event failed: %w", n.n.setWillNavigateHandler, err))
+	}
+}
+
+// handleWillNavigate lets the will navigate hooks decide and sends the decision back to Electron
+// The navigation is allowed only if every hook allows it.
+func (n *navigation) handleWillNavigate(e Event) {
+	// Get hooks
+	n.m.Lock()
+	fns := append([]func(url string) bool{}, n.fns...)
+	n.m.Unlock()
+
+	// Decide
+	allowed := true
+	for _, fn := range fns {
+		if !fn(e.URL) {
+			allowed = false
+			break
+		}
+	}
+
+	// Send decision back
+	if err := n.o.w.write(Event{Allowed: &allowed, CallbackID: e.CallbackID, Name: n.n.willNavigateCallback, TargetID: n.o.id, URL: e.URL}); err != nil {
+		n.l.Error(fmt.Errorf("writing %s event failed: %w", n.n.willNavigateCallback, err))
+	}
+}
@@ -0,0 +1,324 @@
+package astilectron
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+)
+
+// Navigation policy violation kinds
+const (
+	NavigationPolicyViolationFrame      = "frame"
+	NavigationPolicyViolationNavigation = "navigation"
+	NavigationPolicyViolationPopup      = "popup"
+	NavigationPolicyViolationRedirect   = "redirect"
+)
+
+// navigationPolicyPriority makes sure policy handlers are executed first so that violations are cancelled whatever
+// the other handlers decide
+const navigationPolicyPriority = math.MaxInt32
+
+// NavigationPolicy represents a policy listing the origins web contents are allowed to navigate to, load in frames,
+// be redirected to and open popups of
+// Origins are made of a scheme, a host and an optional port (e.g. "app://bundle", "https://example.com:8443"). The
+// host can be "*" to match all hosts or start with "*." to match subdomains as well. Origins without a port only
+// match the default port of their scheme. "about:blank" and "about:srcdoc" are always allowed.
+// OnViolation, if set, is executed with every blocked url.
+type NavigationPolicy struct {
+	AllowedOrigins []string
+	OnViolation    func(v NavigationPolicyViolation)
+}
+
+// NavigationPolicyViolation represents a url blocked by a navigation policy
+type NavigationPolicyViolation struct {
+	Kind string
+	URL  string
+}
+
+// navigationPolicy represents a parsed navigation policy
+type navigationPolicy struct {
+	fn      func(v NavigationPolicyViolation)
+	origins []navigationOrigin
+}
+
+// navigationOrigin represents a parsed origin
+type navigationOrigin struct {
+	host      string
+	port      string // "*" means all ports
+	scheme    string
+	subdomain bool // Whether subdomains of the host are matched as well
+}
+
+// newNavigationPolicy parses a navigation policy
+func newNavigationPolicy(p NavigationPolicy) (np *navigationPolicy, err error) {
+	np = &navigationPolicy{fn: p.OnViolation}
+	for _, raw := range p.AllowedOrigins {
+		var o navigationOrigin
+		if o, err = parseNavigationOrigin(raw); err != nil {
+			err = fmt.Errorf("parsing origin %s failed: %w", raw, err)
+			return
+		}
+		np.origins = append(np.origins, o)
+	}
+	return
+}
+
+// parseNavigationOrigin parses an origin
+func parseNavigationOrigin(raw string) (o navigationOrigin, err error) {
+	// Scheme
+	i := strings.Index(raw, "://")
+	if i <= 0 {
+		err = fmt.Errorf("missing scheme separator")
+		return
+	}
+	o.scheme = strings.ToLower(raw[:i])
+	raw = strings.TrimSuffix(raw[i+3:], "/")
+
+	// Path
+	if strings.Contains(raw, "/") {
+		err = fmt.Errorf("origin can't contain a path")
+		return
+	}
+
+	// Port
+	host := strings.ToLower(raw)
+	if j := strings.LastIndex(host, ":"); j >= 0 && !strings.HasSuffix(host, "]") {
+		host, o.port = host[:j], host[j+1:]
+	}
+
+	// Host
+	switch {
+	case host == "*":
+		o.subdomain = true
+	case strings.HasPrefix(host, "*."):
+		o.host = host[2:]
+		o.subdomain = true
+	default:
+		o.host = strings.Trim(host, "[]")
+	}
+	if strings.Contains(o.host, "*") {
+		err = fmt.Errorf("invalid host %s", host)
+		return
+	}
+	if o.host == "" && !o.subdomain && o.scheme != "file" {
+		err = fmt.Errorf("missing host")
+		return
+	}
+	return
+}
+
+// matches checks whether the origin matches a URL
+func (o navigationOrigin) matches(u *url.URL) bool {
+	// Scheme
+	s := strings.ToLower(u.Scheme)
+	if o.scheme != s {
+		return false
+	}
+
+	// Host
+	h := strings.ToLower(u.Hostname())
+	if o.host != "" && h != o.host && (!o.subdomain || !strings.HasSuffix(h, "."+o.host)) {
+		return false
+	} else if o.host == "" && !o.subdomain && h != "" {
+		return false
+	}
+
+	// Port
+	return o.port == "*" || navigationDefaultPort(s, o.port) == navigationDefaultPort(s, u.Port())
+}
+
+// navigationDefaultPort returns the port or, if it's empty, the default port of the scheme
+func navigationDefaultPort(scheme, port string) string {
+	if port != "" {
+		return port
+	}
+	switch scheme {
+	case "http", "ws":
+		return "80"
+	case "https", "wss":
+		return "443"
+	}
+	return ""
+}
+
+// allows checks whether the policy allows a url
+func (p *navigationPolicy) allows(raw string) bool {
+	// Parse url
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+
+	// Blank pages
+	if strings.ToLower(u.Scheme) == "about" && (u.Opaque == "blank" || u.Opaque == "srcdoc") {
+		return true
+	}
+	return p.matches(u)
+}
+
+// allowsOrigin checks whether an origin matches one of the policy's origins
+// Unlike allows, blank pages are not allowed.
+func (p *navigationPolicy) allowsOrigin(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return p.matches(u)
+}
+
+// matches checks whether a url matches one of the policy's origins
+func (p *navigationPolicy) matches(u *url.URL) bool {
+	for _, o := range p.origins {
+		if o.matches(u) {
+			return true
+		}
+	}
+	return false
+}
+
+// check checks whether the policy allows a url and reports the violation otherwise
+func (p *navigationPolicy) check(kind, url string) bool {
+	if p.allows(url) {
+		return true
+	}
+	if p.fn != nil {
+		p.fn(NavigationPolicyViolation{Kind: kind, URL: url})
+	}
+	return false
+}
+
+// SetNavigationPolicy sets the policy listing the origins the session's main frames and frames can load, including
+// when they're redirected
+// Once a policy is set, every request of the session is forwarded to GO. A nil policy allows every origin again.
+func (s *Session) SetNavigationPolicy(p *NavigationPolicy) (err error) {
+	// Check context
+	if err = s.ctx.Err(); err != nil {
+		return
+	}
+
+	// Parse policy
+	var np *navigationPolicy
+	if p != nil {
+		if np, err = newNavigationPolicy(*p); err != nil {
+			err = fmt.Errorf("creating navigation policy failed: %w", err)
+			return
+		}
+	}
+
+	// Store policy
+	s.m.Lock()
+	s.navigationPolicy = np
+	handled := s.navigationPolicyHandled
+	if np != nil {
+		s.navigationPolicyHandled = true
+	}
+	s.m.Unlock()
+
+	// Handlers have already been added
+	if handled || np == nil {
+		return
+	}
+
+	// Check requests
+	o := WebRequestHandlerOptions{Priority: navigationPolicyPriority}
+	if _, err = s.webRequest.OnBeforeRequest(o, s.checkNavigationPolicy); err != nil {
+		err = fmt.Errorf("adding before request handler failed: %w", err)
+		return
+	}
+
+	// Forget requests once they're done
+	forget := func(d WebRequestDetails) {
+		s.m.Lock()
+		delete(s.navigationRequests, d.ID)
+		s.m.Unlock()
+	}
+	if _, err = s.webRequest.OnCompleted(o, forget); err != nil {
+		err = fmt.Errorf("adding completed handler failed: %w", err)
+		return
+	}
+	if _, err = s.webRequest.OnErrorOccurred(o, forget); err != nil {
+		err = fmt.Errorf("adding error occurred handler failed: %w", err)
+		return
+	}
+	return
+}
+
+// checkNavigationPolicy cancels the frame requests the navigation policy doesn't allow
+// Electron executes the before request handlers of a redirected request once again, with the same request id.
+func (s *Session) checkNavigationPolicy(d WebRequestDetails) (r WebRequestBeforeRequestResponse) {
+	// Only frames are checked
+	if d.ResourceType != WebRequestResourceTypeMainFrame && d.ResourceType != WebRequestResourceTypeSubFrame {
+		return
+	}
+
+	// Get policy and kind
+	s.m.Lock()
+	p := s.navigationPolicy
+	redirect := s.navigationRequests[d.ID]
+	s.navigationRequests[d.ID] = true
+	s.m.Unlock()
+	if p == nil {
+		return
+	}
+	kind := NavigationPolicyViolationNavigation
+	if redirect {
+		kind = NavigationPolicyViolationRedirect
+	} else if d.ResourceType == WebRequestResourceTypeSubFrame {
+		kind = NavigationPolicyViolationFrame
+	}
+
+	// Check
+	r.Cancel = !p.check(kind, d.URL)
+	return
+}
+
+// SetNavigationPolicy sets the policy listing the origins the window can navigate to and open popups of
+// The policy is set on the window's session as well so that frames and redirects are checked too, which means it
+// applies to every window sharing that session. Popups allowed by the policy are handled by the window open handler
+// if any, or opened as child windows otherwise. Child windows share the window's policy, which is set before they load
+// their url, as is the policy of any window whose policy is set before Create. A nil policy allows every origin again.
+func (w *Window) SetNavigationPolicy(p *NavigationPolicy) (err error) {
+	// Check context
+	if err = w.ctx.Err(); err != nil {
+		return
+	}
+
+	// Parse policy
+	var np *navigationPolicy
+	if p != nil {
+		if np, err = newNavigationPolicy(*p); err != nil {
+			err = fmt.Errorf("creating navigation policy failed: %w", err)
+			return
+		}
+	}
+
+	// Set session policy
+	if err = w.Session.SetNavigationPolicy(p); err != nil {
+		err = fmt.Errorf("setting session navigation policy failed: %w", err)
+		return
+	}
+
+	// Store policy
+	w.m.Lock()
+	w.navigationPolicy = np
+	w.navigationPolicyOptions = p
+	hooked := w.navigationPolicyHooked
+	if np != nil {
+		w.navigationPolicyHooked = true
+	}
+	w.m.Unlock()
+
+	// Check navigations
+	if !hooked && np != nil {
+		w.OnWillNavigate(func(url string) bool {
+			w.m.Lock()
+			p := w.navigationPolicy
+			w.m.Unlock()
+			return p == nil || p.check(NavigationPolicyViolationNavigation, url)
+		})
+	}
+
+	// Check popups
+	return w.updateWindowOpenListener()
+}
@@ -0,0 +1,219 @@
+package astilectron
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNavigationPolicy(t *testing.T) {
+	p, err := newNavigationPolicy(NavigationPolicy{AllowedOrigins: []string{"app://bundle", "https://example.com/", "https://*.trusted.com", "http://localhost:*", "file://"}})
+	assert.NoError(t, err)
+	for _, c := range []struct {
+		url     string
+		allowed bool
+	}{
+		{url: "app://bundle/index.html", allowed: true},
+		{url: "app://other/index.html", allowed: false},
+		{url: "https://example.com/page?id=1", allowed: true},
+		{url: "https://EXAMPLE.com:443/", allowed: true},
+		{url: "https://example.com:8443/", allowed: false},
+		{url: "http://example.com/", allowed: false},
+		{url: "https://evil.example.com/", allowed: false},
+		{url: "https://trusted.com/", allowed: true},
+		{url: "https://api.trusted.com/", allowed: true},
+		{url: "https://untrusted.com/", allowed: false},
+		{url: "http://localhost:4000/", allowed: true},
+		{url: "file:///tmp/index.html", allowed: true},
+		{url: "about:blank", allowed: true},
+		{url: "about:config", allowed: false},
+		{url: "javascript:alert(1)", allowed: false},
+	} {
+		assert.Equal(t, c.allowed, p.allows(c.url), c.url)
+	}
+	for _, o := range []string{"example.com", "https://example.com/path", "https://ex*.com", "https://"} {
+		_, err = parseNavigationOrigin(o)
+		assert.Error(t, err, o)
+	}
+}
+
+func TestSession_SetNavigationPolicy(t *testing.T) {
+	// Init
+	d := newDispatcher()
+	i := newIdentifier()
+	wrt := &mockedWriter{wg: &sync.WaitGroup{}}
+	w := newWriter(wrt, &logger{})
+	s := newSession(context.Background(), &logger{}, d, i, w)
+
+	// Invalid policy
+	assert.Error(t, s.SetNavigationPolicy(&NavigationPolicy{AllowedOrigins: []string{"invalid"}}))
+
+	// Set policy
+	var m sync.Mutex
+	var vs []NavigationPolicyViolation
+	wrt.wg.Add(3)
+	err := s.SetNavigationPolicy(&NavigationPolicy{
+		AllowedOrigins: []string{"app://bundle"},
+		OnViolation: func(v NavigationPolicyViolation) {
+			m.Lock()
+			vs = append(vs, v)
+			m.Unlock()
+		},
+	})
+	assert.NoError(t, err)
+	wrt.wg.Wait()
+	assert.Equal(t, []string{
+		"{\"name\":\"" + EventNameSessionCmdWebRequestOnBeforeRequest + "\",\"targetID\":\"1\",\"filter\":{}}\n",
+		"{\"name\":\"" + EventNameSessionCmdWebRequestOnCompleted + "\",\"targetID\":\"1\",\"filter\":{}}\n",
+		"{\"name\":\"" + EventNameSessionCmdWebRequestOnErrorOccurred + "\",\"targetID\":\"1\",\"filter\":{}}\n",
+	}, wrt.w)
+
+	// Requests
+	for _, c := range []struct {
+		cancel bool
+		d      WebRequestDetails
+	}{
+		{d: WebRequestDetails{ID: 1, ResourceType: WebRequestResourceTypeMainFrame, URL: "app://bundle/index.html"}},
+		{cancel: true, d: WebRequestDetails{ID: 1, ResourceType: WebRequestResourceTypeMainFrame, URL: "https://evil.com/"}},
+		{cancel: true, d: WebRequestDetails{ID: 2, ResourceType: WebRequestResourceTypeSubFrame, URL: "https://evil.com/frame"}},
+		{d: WebRequestDetails{ID: 3, ResourceType: WebRequestResourceTypeScript, URL: "https://cdn.com/app.js"}},
+		{cancel: true, d: WebRequestDetails{ID: 4, ResourceType: WebRequestResourceTypeMainFrame, URL: "https://evil.com/"}},
+	} {
+		wrt.w = []string{}
+		wrt.wg.Add(1)
+		d.dispatch(Event{CallbackID: "1", Name: EventNameSessionEventWebRequestOnBeforeRequest, TargetID: s.id, WebRequest: &c.d})
+		wrt.wg.Wait()
+		var e Event
+		json.Unmarshal([]byte(wrt.w[0]), &e)
+		assert.Equal(t, c.cancel, *e.Cancel, c.d.URL)
+	}
+	assert.Equal(t, []NavigationPolicyViolation{
+		{Kind: NavigationPolicyViolationRedirect, URL: "https://evil.com/"},
+		{Kind: NavigationPolicyViolationFrame, URL: "https://evil.com/frame"},
+		{Kind: NavigationPolicyViolationNavigation, URL: "https://evil.com/"},
+	}, vs)
+
+	// Requests are forgotten once done
+	d.dispatch(Event{Name: EventNameSessionEventWebRequestOnCompleted, TargetID: s.id, WebRequest: &WebRequestDetails{ID: 1}})
+	testEventually(t, func() bool {
+		s.m.Lock()
+		defer s.m.Unlock()
+		return !s.navigationRequests[1]
+	})
+
+	// Unset policy
+	wrt.w = []string{}
+	assert.NoError(t, s.SetNavigationPolicy(nil))
+	wrt.wg.Add(1)
+	d.dispatch(Event{CallbackID: "1", Name: EventNameSessionEventWebRequestOnBeforeRequest, TargetID: s.id, WebRequest: &WebRequestDetails{ID: 5, ResourceType: WebRequestResourceTypeMainFrame, URL: "https://evil.com/"}})
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameSessionEventWebRequestOnBeforeRequestCallback + "\",\"targetID\":\"1\",\"cancel\":false,\"callbackId\":\"1\"}\n"}, wrt.w)
+}
+
+func TestWindow_SetNavigationPolicy(t *testing.T) {
+	// Init
+	a, err := New(nil, Options{})
+	assert.NoError(t, err)
+	defer a.Close()
+	wrt := &mockedWriter{}
+	a.writer = newWriter(wrt, &logger{})
+	w, err := a.NewWindow("http://test.com", &WindowOptions{})
+	assert.NoError(t, err)
+	w.created = true
+
+	// Set policy
+	var m sync.Mutex
+	var vs []NavigationPolicyViolation
+	var es []Event
+	wrt.fn = func() {
+		var e Event
+		json.Unmarshal([]byte(wrt.w[len(wrt.w)-1]), &e)
+		m.Lock()
+		es = append(es, e)
+		m.Unlock()
+		if e.Name == EventNameWindowCmdCreate {
+			w.d.dispatch(Event{CallbackID: e.CallbackID, Name: EventNameWindowEventDidFinishLoad, TargetID: e.TargetID})
+		}
+	}
+	events := func(n int) []Event {
+		testEventually(t, func() bool {
+			m.Lock()
+			defer m.Unlock()
+			return len(es) == n
+		})
+		m.Lock()
+		defer m.Unlock()
+		r := es
+		es = []Event{}
+		return r
+	}
+	err = w.SetNavigationPolicy(&NavigationPolicy{
+		AllowedOrigins: []string{"http://test.com"},
+		OnViolation: func(v NavigationPolicyViolation) {
+			m.Lock()
+			vs = append(vs, v)
+			m.Unlock()
+		},
+	})
+	assert.NoError(t, err)
+	var ns []string
+	for _, e := range events(5) {
+		ns = append(ns, e.Name)
+	}
+	assert.Equal(t, []string{
+		EventNameSessionCmdWebRequestOnBeforeRequest,
+		EventNameSessionCmdWebRequestOnCompleted,
+		EventNameSessionCmdWebRequestOnErrorOccurred,
+		EventNameWindowCmdWebContentsSetWillNavigateHandler,
+		EventNameWindowCmdSetWindowOpenHandler,
+	}, ns)
+
+	// Will navigate
+	w.d.dispatch(Event{CallbackID: "1", Name: EventNameWindowEventWebContentsWillNavigate, TargetID: w.id, URL: "http://evil.com/"})
+	e := events(1)[0]
+	assert.Equal(t, EventNameWindowEventWebContentsWillNavigateCallback, e.Name)
+	assert.False(t, *e.Allowed)
+
+	// Popup
+	w.d.dispatch(Event{CallbackID: "2", Name: EventNameWindowEventWindowOpen, TargetID: w.id, WindowOpen: &WindowOpenDetails{URL: "http://evil.com/popup"}})
+	assert.Equal(t, []Event{{CallbackID: "2", Name: EventNameWindowEventWindowOpenCallback, TargetID: w.id, WindowOpenAction: WindowOpenActionDeny}}, events(1))
+	m.Lock()
+	assert.Equal(t, []NavigationPolicyViolation{
+		{Kind: NavigationPolicyViolationNavigation, URL: "http://evil.com/"},
+		{Kind: NavigationPolicyViolationPopup, URL: "http://evil.com/popup"},
+	}, vs)
+	m.Unlock()
+
+	// Allowed popups inherit the policy before being created
+	w.d.dispatch(Event{CallbackID: "3", Name: EventNameWindowEventWindowOpen, TargetID: w.id, WindowOpen: &WindowOpenDetails{URL: "http://test.com/popup"}})
+	testEventually(t, func() bool {
+		m.Lock()
+		defer m.Unlock()
+		return len(es) > 0 && es[len(es)-1].Name == EventNameWindowEventWindowOpenCallback
+	})
+	cs := w.ChildWindows()
+	assert.Len(t, cs, 1)
+	m.Lock()
+	var ce *Event
+	for idx, e := range es {
+		assert.False(t, e.TargetID == cs[0].id && e.Name != EventNameWindowCmdCreate, e.Name)
+		if e.Name == EventNameWindowCmdCreate {
+			ce = &es[idx]
+		}
+	}
+	es = []Event{}
+	m.Unlock()
+	if assert.NotNil(t, ce) {
+		assert.Equal(t, []string{EventNameWindowCmdWebContentsSetWillNavigateHandler, EventNameWindowCmdSetWindowOpenHandler}, ce.Handlers)
+	}
+
+	// Unset policy
+	assert.NoError(t, w.SetNavigationPolicy(nil))
+	assert.Equal(t, EventNameWindowCmdUnsetWindowOpenHandler, events(1)[0].Name)
+	w.d.dispatch(Event{CallbackID: "4", Name: EventNameWindowEventWebContentsWillNavigate, TargetID: w.id, URL: "http://evil.com/"})
+	assert.True(t, *events(1)[0].Allowed)
+	wrt.fn = nil
+}
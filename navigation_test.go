@@ -0,0 +1,73 @@
+package astilectron
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNavigation(t *testing.T) {
+	// Init
+	a, err := New(nil, Options{})
+	assert.NoError(t, err)
+	defer a.Close()
+	wrt := &mockedWriter{}
+	a.writer = newWriter(wrt, &logger{})
+	w, err := a.NewWindow("http://test.com", &WindowOptions{})
+	assert.NoError(t, err)
+	b, err := a.NewBrowserView("", &WindowOptions{}, nil)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	// Commands
+	testObjectRequest(t, func() error { return w.GoBack(ctx) }, w.object, wrt, "{\"name\":\""+EventNameWindowCmdWebContentsGoBack+"\",\"targetID\":\""+w.id+"\",\"callbackId\":\"4\"}\n", EventNameWindowEventWebContentsWentBack, Event{})
+	testObjectRequest(t, func() error { return w.GoForward(ctx) }, w.object, wrt, "{\"name\":\""+EventNameWindowCmdWebContentsGoForward+"\",\"targetID\":\""+w.id+"\",\"callbackId\":\"5\"}\n", EventNameWindowEventWebContentsWentForward, Event{})
+	testObjectRequest(t, func() error { return w.GoToIndex(ctx, 2) }, w.object, wrt, "{\"name\":\""+EventNameWindowCmdWebContentsGoToIndex+"\",\"targetID\":\""+w.id+"\",\"callbackId\":\"6\",\"index\":2}\n", EventNameWindowEventWebContentsWentToIndex, Event{})
+	testObjectRequest(t, func() error { return w.Reload(ctx) }, w.object, wrt, "{\"name\":\""+EventNameWindowCmdWebContentsReload+"\",\"targetID\":\""+w.id+"\",\"callbackId\":\"7\"}\n", EventNameWindowEventWebContentsReloaded, Event{})
+	testObjectRequest(t, func() error { return b.ReloadIgnoringCache(ctx) }, b.object, wrt, "{\"name\":\""+EventNameBrowserViewCmdWebContentsReloadIgnoringCache+"\",\"targetID\":\""+b.id+"\",\"callbackId\":\"8\"}\n", EventNameBrowserViewEventWebContentsReloadedIgnoringCache, Event{})
+	testObjectRequest(t, func() error { return b.Stop(ctx) }, b.object, wrt, "{\"name\":\""+EventNameBrowserViewCmdWebContentsStop+"\",\"targetID\":\""+b.id+"\",\"callbackId\":\"9\"}\n", EventNameBrowserViewEventWebContentsStopped, Event{})
+
+	// History
+	eh := NavigationHistory{Entries: []NavigationEntry{{Title: "1", URL: "http://test.com/1"}, {Title: "2", URL: "http://test.com/2"}}, Index: 1}
+	var h NavigationHistory
+	testObjectRequest(t, func() (err error) {
+		h, err = w.NavigationHistory(ctx)
+		return
+	}, w.object, wrt, "{\"name\":\""+EventNameWindowCmdWebContentsGetNavigationHistory+"\",\"targetID\":\""+w.id+"\",\"callbackId\":\"10\"}\n", EventNameWindowEventWebContentsGotNavigationHistory, Event{NavigationHistory: &eh})
+	assert.Equal(t, eh, h)
+	var back, forward bool
+	testObjectRequest(t, func() (err error) {
+		back, err = w.CanGoBack(ctx)
+		return
+	}, w.object, wrt, "{\"name\":\""+EventNameWindowCmdWebContentsGetNavigationHistory+"\",\"targetID\":\""+w.id+"\",\"callbackId\":\"11\"}\n", EventNameWindowEventWebContentsGotNavigationHistory, Event{NavigationHistory: &eh})
+	assert.True(t, back)
+	testObjectRequest(t, func() (err error) {
+		forward, err = w.CanGoForward(ctx)
+		return
+	}, w.object, wrt, "{\"name\":\""+EventNameWindowCmdWebContentsGetNavigationHistory+"\",\"targetID\":\""+w.id+"\",\"callbackId\":\"12\"}\n", EventNameWindowEventWebContentsGotNavigationHistory, Event{NavigationHistory: &eh})
+	assert.False(t, forward)
+
+	// Will navigate
+	var wg sync.WaitGroup
+	wrt.w = []string{}
+	wrt.fn = func() { wg.Done() }
+	wg.Add(1)
+	b.OnWillNavigate(func(url string) bool { return url != "http://evil.com" })
+	b.OnWillNavigate(func(url string) bool { return true })
+	wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameBrowserViewCmdWebContentsSetWillNavigateHandler + "\",\"targetID\":\"" + b.id + "\"}\n"}, wrt.w)
+	for _, u := range []string{"http://test.com", "http://evil.com"} {
+		wrt.w = []string{}
+		wg.Add(1)
+		b.d.dispatch(Event{CallbackID: "1", Name: EventNameBrowserViewEventWebContentsWillNavigate, TargetID: b.id, URL: u})
+		wg.Wait()
+		var e Event
+		json.Unmarshal([]byte(wrt.w[0]), &e)
+		assert.Equal(t, EventNameBrowserViewEventWebContentsWillNavigateCallback, e.Name)
+		assert.Equal(t, "1", e.CallbackID)
+		assert.Equal(t, u == "http://test.com", *e.Allowed)
+	}
+}
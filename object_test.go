@@ -2,11 +2,27 @@ package astilectron
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// testEventually checks a condition every millisecond until it's satisfied or a second has passed
+// assert.Eventually checks conditions in goroutines that may outlive it, in which case they panic.
+func testEventually(t *testing.T, condition func() bool) bool {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		if condition() {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return assert.Fail(t, "Condition never satisfied")
+		}
+	}
+}
+
 func testObjectAction(t *testing.T, fn func() error, o *object, wrt *mockedWriter, sentEvent, eventNameDone string) {
 	wrt.w = []string{}
 	o.cancel()
@@ -20,3 +36,23 @@ func testObjectAction(t *testing.T, fn func() error, o *object, wrt *mockedWrite
 	assert.NoError(t, err)
 	assert.Equal(t, []string{sentEvent}, wrt.w)
 }
+
+func testObjectRequest(t *testing.T, fn func() error, o *object, wrt *mockedWriter, sentEvent, eventNameDone string, done Event) {
+	wrt.w = []string{}
+	o.cancel()
+	err := fn()
+	assert.EqualError(t, err, context.Canceled.Error())
+	o.ctx, o.cancel = context.WithCancel(context.Background())
+	wrt.fn = func() {
+		var e Event
+		json.Unmarshal([]byte(wrt.w[len(wrt.w)-1]), &e)
+		done.CallbackID = e.CallbackID
+		done.Name = eventNameDone
+		done.TargetID = o.id
+		o.d.dispatch(done)
+	}
+	err = fn()
+	wrt.fn = nil
+	assert.NoError(t, err)
+	assert.Equal(t, []string{sentEvent}, wrt.w)
+}
@@ -16,6 +16,7 @@ type Paths struct {
 	astilectronDirectory   string
 	astilectronDownloadSrc string
 	astilectronDownloadDst string
+	astilectronPreload     string
 	astilectronUnzipSrc    string
 	baseDirectory          string
 	dataDirectory          string
@@ -57,6 +58,7 @@ func newPaths(os, arch string, o Options) (p *Paths, err error) {
 	p.provisionStatus = filepath.Join(p.vendorDirectory, "status.json")
 	p.astilectronDirectory = filepath.Join(p.vendorDirectory, "astilectron")
 	p.astilectronApplication = filepath.Join(p.astilectronDirectory, "main.js")
+	p.astilectronPreload = filepath.Join(p.astilectronDirectory, "preload.js")
 	p.astilectronDownloadSrc = AstilectronDownloadSrc(o.VersionAstilectron)
 	p.astilectronDownloadDst = filepath.Join(p.vendorDirectory, fmt.Sprintf("astilectron-v%s.zip", o.VersionAstilectron))
 	p.astilectronUnzipSrc = filepath.Join(p.astilectronDownloadDst, fmt.Sprintf("astilectron-%s", o.VersionAstilectron))
@@ -203,6 +205,11 @@ func (p Paths) AstilectronDownloadSrc() string {
 	return p.astilectronDownloadSrc
 }
 
+// AstilectronPreload returns the path of the astilectron preload used by secure windows
+func (p Paths) AstilectronPreload() string {
+	return p.astilectronPreload
+}
+
 // AstilectronUnzipSrc returns the astilectron unzip source path
 func (p Paths) AstilectronUnzipSrc() string {
 	return p.astilectronUnzipSrc
@@ -0,0 +1,141 @@
+package astilectron
+
+import "fmt"
+
+// Permission event names
+const (
+	EventNameSessionCmdSetPermissionCheckPolicy      = "session.cmd.set.permission.check.policy"
+	EventNameSessionCmdSetPermissionRequestHandler   = "session.cmd.set.permission.request.handler"
+	EventNameSessionCmdUnsetPermissionCheckPolicy    = "session.cmd.unset.permission.check.policy"
+	EventNameSessionCmdUnsetPermissionRequestHandler = "session.cmd.unset.permission.request.handler"
+	EventNameSessionEventPermissionRequest           = "session.event.permission.request"
+	EventNameSessionEventPermissionRequestCallback   = "session.event.permission.request.callback"
+)
+
+// Permissions
+const (
+	PermissionClipboardRead           = "clipboard-read"
+	PermissionClipboardSanitizedWrite = "clipboard-sanitized-write"
+	PermissionDisplayCapture          = "display-capture"
+	PermissionFullscreen              = "fullscreen"
+	PermissionGeolocation             = "geolocation"
+	PermissionMedia                   = "media"
+	PermissionMediaKeySystem          = "mediaKeySystem"
+	PermissionMIDI                    = "midi"
+	PermissionMIDISysex               = "midiSysex"
+	PermissionNotifications           = "notifications"
+	PermissionOpenExternal            = "openExternal"
+	PermissionPointerLock             = "pointerLock"
+	PermissionUnknown                 = "unknown"
+)
+
+// PermissionRequest represents a permission request
+// WindowID is the id of the window the request originates from, if any
+// https://github.com/electron/electron/blob/v11.4.3/docs/api/session.md#sessetpermissionrequesthandlerhandler
+type PermissionRequest struct {
+	Details          PermissionRequestDetails `json:"details"`
+	Permission       string                   `json:"permission"`
+	RequestingOrigin string                   `json:"requestingOrigin,omitempty"`
+	WebContentsID    *int                     `json:"webContentsId,omitempty"`
+	WindowID         string                   `json:"windowId,omitempty"`
+}
+
+// PermissionRequestDetails represents the details of a permission request
+// Depending on the permission, some attributes may be empty
+type PermissionRequestDetails struct {
+	EmbeddingOrigin string   `json:"embeddingOrigin,omitempty"`
+	ExternalURL     string   `json:"externalURL,omitempty"`
+	IsMainFrame     bool     `json:"isMainFrame,omitempty"`
+	MediaType       string   `json:"mediaType,omitempty"`
+	MediaTypes      []string `json:"mediaTypes,omitempty"`
+	RequestingURL   string   `json:"requestingUrl,omitempty"`
+	SecurityOrigin  string   `json:"securityOrigin,omitempty"`
+}
+
+// PermissionCheckPolicy represents a static policy deciding whether permission checks are allowed
+// Electron expects permission checks to be answered synchronously, which a round trip to GO can't do: the policy is
+// therefore sent to Electron, which evaluates it by itself. A check is allowed if its permission is listed in
+// AllowedPermissions and, unless AllowedOrigins is empty, its requesting origin (e.g. "https://example.com") is listed
+// in AllowedOrigins.
+// https://github.com/electron/electron/blob/v11.4.3/docs/api/session.md#sessetpermissioncheckhandlerhandler
+type PermissionCheckPolicy struct {
+	AllowedOrigins     []string `json:"allowedOrigins,omitempty"`
+	AllowedPermissions []string `json:"allowedPermissions,omitempty"`
+}
+
+// permissionHandler represents a GO permission handler
+type permissionHandler struct {
+	fn        func(r PermissionRequest) (allowed bool)
+	listening bool
+}
+
+// SetPermissionRequestHandler sets the handler deciding whether permission requests of the session are allowed
+// Unlike permission checks, Electron lets permission requests be answered asynchronously. A nil handler restores
+// Electron's default behavior, which allows every request.
+func (s *Session) SetPermissionRequestHandler(fn func(r PermissionRequest) (allowed bool)) (err error) {
+	// Check context
+	if err = s.ctx.Err(); err != nil {
+		return
+	}
+
+	// Store handler
+	s.m.Lock()
+	s.permissionRequest.fn = fn
+	listening := s.permissionRequest.listening
+	if fn != nil {
+		s.permissionRequest.listening = true
+	}
+	s.m.Unlock()
+
+	// Unset
+	if fn == nil {
+		return s.w.write(Event{Name: EventNameSessionCmdUnsetPermissionRequestHandler, TargetID: s.id})
+	}
+
+	// Listen
+	if !listening {
+		s.On(EventNameSessionEventPermissionRequest, func(e Event) (deleteListener bool) {
+			s.handlePermissionRequest(e)
+			return
+		})
+	}
+	return s.w.write(Event{Name: EventNameSessionCmdSetPermissionRequestHandler, TargetID: s.id})
+}
+
+// handlePermissionRequest lets the permission request handler decide and sends the decision back to Electron
+func (s *Session) handlePermissionRequest(e Event) {
+	// Get handler
+	s.m.Lock()
+	fn := s.permissionRequest.fn
+	s.m.Unlock()
+
+	// Decide, denying the request if the handler has been unset in the meantime
+	allowed := false
+	if fn != nil {
+		var r PermissionRequest
+		if e.Permission != nil {
+			r = *e.Permission
+		}
+		allowed = fn(r)
+	}
+
+	// Send decision back
+	if err := s.w.write(Event{Allowed: &allowed, CallbackID: e.CallbackID, Name: EventNameSessionEventPermissionRequestCallback, TargetID: s.id}); err != nil {
+		s.l.Error(fmt.Errorf("writing %s event failed: %w", EventNameSessionEventPermissionRequestCallback, err))
+	}
+}
+
+// SetPermissionCheckPolicy sets the policy deciding whether permission checks of the session are allowed
+// A nil policy restores Electron's default behavior, which allows every check.
+func (s *Session) SetPermissionCheckPolicy(p *PermissionCheckPolicy) (err error) {
+	// Check context
+	if err = s.ctx.Err(); err != nil {
+		return
+	}
+
+	// Unset
+	if p == nil {
+		return s.w.write(Event{Name: EventNameSessionCmdUnsetPermissionCheckPolicy, TargetID: s.id})
+	}
+	return s.w.write(Event{Name: EventNameSessionCmdSetPermissionCheckPolicy, PermissionCheckPolicy: p, TargetID: s.id})
+}
@@ -0,0 +1,67 @@
+package astilectron
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession_PermissionHandlers(t *testing.T) {
+	// Init
+	d := newDispatcher()
+	i := newIdentifier()
+	wrt := &mockedWriter{}
+	w := newWriter(wrt, &logger{})
+	s := newSession(context.Background(), &logger{}, d, i, w)
+
+	// Set
+	var r PermissionRequest
+	assert.NoError(t, s.SetPermissionRequestHandler(func(i PermissionRequest) bool {
+		r = i
+		return i.Permission == PermissionNotifications && i.RequestingOrigin == "https://example.com"
+	}))
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameSessionCmdSetPermissionRequestHandler + "\",\"targetID\":\"1\"}\n"}, wrt.w)
+
+	// Request
+	wrt.w = []string{}
+	wrt.wg = &sync.WaitGroup{}
+	wrt.wg.Add(1)
+	d.dispatch(Event{CallbackID: "1", Name: EventNameSessionEventPermissionRequest, Permission: &PermissionRequest{Details: PermissionRequestDetails{IsMainFrame: true}, Permission: PermissionNotifications, RequestingOrigin: "https://example.com", WindowID: "2"}, TargetID: s.id})
+	wrt.wg.Wait()
+	assert.Equal(t, PermissionRequest{Details: PermissionRequestDetails{IsMainFrame: true}, Permission: PermissionNotifications, RequestingOrigin: "https://example.com", WindowID: "2"}, r)
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameSessionEventPermissionRequestCallback + "\",\"targetID\":\"1\",\"allowed\":true,\"callbackId\":\"1\"}\n"}, wrt.w)
+
+	// Unset
+	wrt.w = []string{}
+	wrt.wg.Add(1)
+	assert.NoError(t, s.SetPermissionRequestHandler(nil))
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameSessionCmdUnsetPermissionRequestHandler + "\",\"targetID\":\"1\"}\n"}, wrt.w)
+
+	// Requests received once the handler has been unset are denied
+	wrt.w = []string{}
+	wrt.wg.Add(1)
+	d.dispatch(Event{CallbackID: "2", Name: EventNameSessionEventPermissionRequest, Permission: &PermissionRequest{Permission: PermissionMedia}, TargetID: s.id})
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameSessionEventPermissionRequestCallback + "\",\"targetID\":\"1\",\"allowed\":false,\"callbackId\":\"2\"}\n"}, wrt.w)
+}
+
+func TestSession_SetPermissionCheckPolicy(t *testing.T) {
+	// Init
+	d := newDispatcher()
+	i := newIdentifier()
+	wrt := &mockedWriter{}
+	w := newWriter(wrt, &logger{})
+	s := newSession(context.Background(), &logger{}, d, i, w)
+
+	// Set
+	assert.NoError(t, s.SetPermissionCheckPolicy(&PermissionCheckPolicy{AllowedOrigins: []string{"https://example.com"}, AllowedPermissions: []string{PermissionMedia}}))
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameSessionCmdSetPermissionCheckPolicy + "\",\"targetID\":\"1\",\"permissionCheckPolicy\":{\"allowedOrigins\":[\"https://example.com\"],\"allowedPermissions\":[\"media\"]}}\n"}, wrt.w)
+
+	// Unset
+	wrt.w = []string{}
+	assert.NoError(t, s.SetPermissionCheckPolicy(nil))
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameSessionCmdUnsetPermissionCheckPolicy + "\",\"targetID\":\"1\"}\n"}, wrt.w)
+}
@@ -0,0 +1,71 @@
+package astilectron
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// astilectronPreloadScript is the preload of secure windows
+// The page doesn't have access to node, which is why the preload exposes the astilectron namespace through the
+// contextBridge and forwards messages through ipcRenderer, using the same channels as the astilectron namespace of
+// regular windows.
+const astilectronPreloadScript = `const { contextBridge, ipcRenderer } = require('electron')
+
+let callbackID = 0
+const callbacks = {}
+const listeners = []
+
+ipcRenderer.on('` + eventNameWindowCmdMessage + `', (event, m) => {
+    let reply
+    if (typeof m.callbackId !== 'undefined') {
+        reply = message => ipcRenderer.send('` + eventNameWindowEventMessageCallback + `', {callbackId: m.callbackId, message: message})
+    }
+    for (const l of listeners) {
+        const r = l(m.message)
+        if (reply && typeof r !== 'undefined') {
+            reply(r)
+        }
+    }
+})
+
+ipcRenderer.on('` + eventNameWindowCmdMessageCallback + `', (event, m) => {
+    const c = callbacks[m.callbackId]
+    if (!c) return
+    delete callbacks[m.callbackId]
+    c(m.message)
+})
+
+contextBridge.exposeInMainWorld('astilectron', {
+    onMessage: l => { listeners.push(l) },
+    sendMessage: (message, callback) => {
+        const m = {message: message}
+        if (typeof callback === 'function') {
+            callbackID++
+            m.callbackId = String(callbackID)
+            callbacks[m.callbackId] = callback
+        }
+        ipcRenderer.send('` + eventNameWindowEventMessage + `', m)
+    }
+})
+
+window.addEventListener('DOMContentLoaded', () => document.dispatchEvent(new Event('astilectron-ready')))
+`
+
+// ProvisionAstilectronPreload writes the astilectron preload used by secure windows
+// The default provisioners execute it, custom provisioners must execute it as well for secure windows to work.
+func ProvisionAstilectronPreload(p Paths) (err error) {
+	// Create directory
+	if err = os.MkdirAll(filepath.Dir(p.AstilectronPreload()), 0755); err != nil {
+		err = fmt.Errorf("mkdirall %s failed: %w", filepath.Dir(p.AstilectronPreload()), err)
+		return
+	}
+
+	// Write
+	if err = ioutil.WriteFile(p.AstilectronPreload(), []byte(astilectronPreloadScript), 0644); err != nil {
+		err = fmt.Errorf("writing %s failed: %w", p.AstilectronPreload(), err)
+		return
+	}
+	return
+}
@@ -0,0 +1,291 @@
+package astilectron
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/asticode/go-astikit"
+)
+
+// Protocol event names
+const (
+	EventNameProtocolCmdHandle                = "protocol.cmd.handle"
+	EventNameProtocolCmdUnhandle              = "protocol.cmd.unhandle"
+	EventNameProtocolEventHandled             = "protocol.event.handled"
+	EventNameProtocolEventRequest             = "protocol.event.request"
+	EventNameProtocolEventRequestCallback     = "protocol.event.request.callback"
+	EventNameProtocolEventRequestCallbackData = "protocol.event.request.callback.data"
+	EventNameProtocolEventRequestCallbackEnd  = "protocol.event.request.callback.end"
+	EventNameProtocolEventUnhandled           = "protocol.event.unhandled"
+)
+
+// Protocol misc
+const (
+	protocolChunkSize     = 64 * 1024
+	protocolSchemesSwitch = "--astilectron-protocol-schemes"
+)
+
+// ProtocolScheme represents a custom scheme that is registered as privileged before Electron's app is ready
+// https://github.com/electron/electron/blob/v11.4.3/docs/api/protocol.md#protocolregisterschemesasprivilegedcustomschemes
+type ProtocolScheme struct {
+	Privileges *ProtocolSchemePrivileges `json:"privileges,omitempty"`
+	Scheme     string                    `json:"scheme"`
+}
+
+// ProtocolSchemePrivileges represents custom scheme privileges
+// We must use pointers since GO doesn't handle optional fields whereas NodeJS does. Use astikit.BoolPtr to fill the
+// struct
+type ProtocolSchemePrivileges struct {
+	AllowServiceWorkers *bool `json:"allowServiceWorkers,omitempty"`
+	BypassCSP           *bool `json:"bypassCSP,omitempty"`
+	CORSEnabled         *bool `json:"corsEnabled,omitempty"`
+	Secure              *bool `json:"secure,omitempty"`
+	Standard            *bool `json:"standard,omitempty"`
+	Stream              *bool `json:"stream,omitempty"`
+	SupportFetchAPI     *bool `json:"supportFetchAPI,omitempty"`
+}
+
+// protocolHandlers represents an object capable of serving custom protocols with http.Handlers
+type protocolHandlers struct {
+	h        map[string]protocolHandler // Indexed by scheme
+	l        astikit.SeverityLogger
+	ln       listenable
+	m        sync.Mutex // Locks h
+	targetID string
+}
+
+// protocolHandler represents the handler of a scheme along with the context of its requests and the writer of its
+// responses
+// Once the scheme is unhandled, its entry is kept without handler so that requests that were already on their way are
+// answered with a not found error.
+type protocolHandler struct {
+	ctx context.Context
+	h   http.Handler
+	w   *writer
+}
+
+// newProtocolHandlers creates new protocol handlers
+func newProtocolHandlers(l astikit.SeverityLogger, ln listenable, targetID string) (p *protocolHandlers) {
+	p = &protocolHandlers{
+		h:        make(map[string]protocolHandler),
+		l:        l,
+		ln:       ln,
+		targetID: targetID,
+	}
+	ln.On(EventNameProtocolEventRequest, func(e Event) (deleteListener bool) {
+		p.serve(e)
+		return
+	})
+	return
+}
+
+// handle registers a handler for a scheme
+func (p *protocolHandlers) handle(ctx context.Context, w *writer, scheme string, h http.Handler) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	p.m.Lock()
+	p.h[scheme] = protocolHandler{ctx: ctx, h: h, w: w}
+	p.m.Unlock()
+	_, err = synchronousEvent(ctx, p.ln, w, Event{Name: EventNameProtocolCmdHandle, TargetID: p.targetID, Scheme: scheme}, EventNameProtocolEventHandled)
+	return
+}
+
+// unhandle unregisters the handler of a scheme
+func (p *protocolHandlers) unhandle(ctx context.Context, w *writer, scheme string) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	p.m.Lock()
+	p.h[scheme] = protocolHandler{ctx: ctx, w: w}
+	p.m.Unlock()
+	_, err = synchronousEvent(ctx, p.ln, w, Event{Name: EventNameProtocolCmdUnhandle, TargetID: p.targetID, Scheme: scheme}, EventNameProtocolEventUnhandled)
+	return
+}
+
+// serve serves a request with the handler of its scheme
+func (p *protocolHandlers) serve(e Event) {
+	// Get handler
+	p.m.Lock()
+	h, ok := p.h[e.Scheme]
+	p.m.Unlock()
+
+	// Scheme has never been handled
+	if !ok {
+		p.l.Error(fmt.Errorf("serving %s request failed: scheme has never been handled", e.Scheme))
+		return
+	}
+
+	// Create response writer
+	rw := newProtocolResponseWriter(p.l, h.w, p.targetID, e.CallbackID)
+	defer rw.close()
+
+	// Scheme has been unhandled
+	if h.h == nil {
+		http.NotFound(rw, nil)
+		return
+	}
+
+	// Create request
+	r, err := newProtocolRequest(h.ctx, e)
+	if err != nil {
+		p.l.Error(fmt.Errorf("creating protocol request failed: %w", err))
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	// Serve
+	defer rw.recoverPanic(r)
+	h.h.ServeHTTP(rw, r)
+}
+
+// recoverPanic recovers from a panic in a handler and, as net/http does, answers with an internal server error if the
+// response hasn't been sent yet
+func (rw *protocolResponseWriter) recoverPanic(r *http.Request) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	if v != http.ErrAbortHandler {
+		rw.l.Error(fmt.Errorf("serving %s panicked: %v", r.URL, v))
+	}
+	if !rw.sent {
+		rw.b.Reset()
+		rw.code = 0
+		rw.h = make(http.Header)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// newProtocolRequest creates an http.Request based on a protocol request event
+func newProtocolRequest(ctx context.Context, e Event) (r *http.Request, err error) {
+	// No request
+	if e.Request == nil {
+		err = fmt.Errorf("event %s has no request", e.Name)
+		return
+	}
+
+	// Create request
+	m := e.Request.Method
+	if m == "" {
+		m = http.MethodGet
+	}
+	if r, err = http.NewRequest(m, e.Request.URL, bytes.NewReader(e.Request.Body)); err != nil {
+		err = fmt.Errorf("creating request failed: %w", err)
+		return
+	}
+	r = r.WithContext(ctx)
+	r.RequestURI = r.URL.RequestURI()
+
+	// Add headers
+	for k, v := range e.Request.Headers {
+		r.Header.Set(k, v)
+	}
+	if e.Request.Referrer != "" && r.Header.Get("Referer") == "" {
+		r.Header.Set("Referer", e.Request.Referrer)
+	}
+	return
+}
+
+// protocolResponseWriter implements the http.ResponseWriter and http.Flusher interfaces and streams the response
+// back to Electron in chunks
+type protocolResponseWriter struct {
+	b          *bytes.Buffer
+	callbackID string
+	code       int
+	h          http.Header
+	l          astikit.SeverityLogger
+	sent       bool
+	targetID   string
+	w          *writer
+}
+
+// newProtocolResponseWriter creates a new protocol response writer
+func newProtocolResponseWriter(l astikit.SeverityLogger, w *writer, targetID, callbackID string) *protocolResponseWriter {
+	return &protocolResponseWriter{
+		b:          &bytes.Buffer{},
+		callbackID: callbackID,
+		h:          make(http.Header),
+		l:          l,
+		targetID:   targetID,
+		w:          w,
+	}
+}
+
+// Header implements the http.ResponseWriter interface
+func (w *protocolResponseWriter) Header() http.Header {
+	return w.h
+}
+
+// WriteHeader implements the http.ResponseWriter interface
+func (w *protocolResponseWriter) WriteHeader(code int) {
+	if w.code > 0 {
+		return
+	}
+	w.code = code
+}
+
+// Write implements the http.ResponseWriter interface
+func (w *protocolResponseWriter) Write(b []byte) (n int, err error) {
+	w.WriteHeader(http.StatusOK)
+	if n, err = w.b.Write(b); err != nil {
+		return
+	}
+	if w.b.Len() >= protocolChunkSize {
+		w.Flush()
+	}
+	return
+}
+
+// Flush implements the http.Flusher interface
+func (w *protocolResponseWriter) Flush() {
+	// Send status code and headers
+	if !w.sent {
+		w.WriteHeader(http.StatusOK)
+		if w.h.Get("Content-Type") == "" && w.b.Len() > 0 {
+			w.h.Set("Content-Type", http.DetectContentType(w.b.Bytes()))
+		}
+		w.write(Event{Headers: w.h, Name: EventNameProtocolEventRequestCallback, StatusCode: astikit.IntPtr(w.code)})
+		w.sent = true
+	}
+
+	// Send data
+	for w.b.Len() > 0 {
+		w.write(Event{Bytes: w.b.Next(protocolChunkSize), Name: EventNameProtocolEventRequestCallbackData})
+	}
+}
+
+// close flushes the response and lets Electron know the response is complete
+func (w *protocolResponseWriter) close() {
+	w.Flush()
+	w.write(Event{Name: EventNameProtocolEventRequestCallbackEnd})
+}
+
+// write writes an event related to the response
+func (w *protocolResponseWriter) write(e Event) {
+	e.CallbackID = w.callbackID
+	e.TargetID = w.targetID
+	if w.w == nil {
+		w.l.Error(fmt.Errorf("writing %s event failed: no writer", e.Name))
+		return
+	}
+	if err := w.w.write(e); err != nil {
+		w.l.Error(fmt.Errorf("writing %s event failed: %w", e.Name, err))
+	}
+}
+
+// protocolSchemesSwitchValue returns the command line switch used to let Electron know which schemes must be
+// registered as privileged
+func protocolSchemesSwitchValue(ss []ProtocolScheme) (s string, err error) {
+	var b []byte
+	if b, err = json.Marshal(ss); err != nil {
+		err = fmt.Errorf("marshaling failed: %w", err)
+		return
+	}
+	s = protocolSchemesSwitch + "=" + string(b)
+	return
+}
@@ -0,0 +1,117 @@
+package astilectron
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/asticode/go-astikit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProtocolHandlers(t *testing.T) {
+	// Init
+	d := newDispatcher()
+	i := newIdentifier()
+	wrt := &mockedWriter{}
+	w := newWriter(wrt, &logger{})
+	s := newSession(context.Background(), &logger{}, d, i, w)
+
+	// Handle
+	testObjectAction(t, func() error {
+		return s.HandleProtocol("app", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			b, _ := ioutil.ReadAll(r.Body)
+			rw.Header().Set("X-Method", r.Method)
+			rw.Header().Set("X-Header", r.Header.Get("X-Header"))
+			rw.WriteHeader(http.StatusCreated)
+			rw.Write([]byte(r.URL.Path + ":" + string(b)))
+			rw.(http.Flusher).Flush()
+			rw.Write([]byte(strings.Repeat("a", protocolChunkSize+1)))
+		}))
+	}, s.object, wrt, "{\"name\":\""+EventNameProtocolCmdHandle+"\",\"targetID\":\"1\",\"scheme\":\"app\"}\n", EventNameProtocolEventHandled)
+
+	// Serve
+	wrt.fn = nil
+	wrt.w = []string{}
+	wrt.wg = &sync.WaitGroup{}
+	wrt.wg.Add(5)
+	d.dispatch(Event{CallbackID: "2", Name: EventNameProtocolEventRequest, Request: &EventRequest{Body: []byte("body"), Headers: map[string]string{"X-Header": "header"}, Method: http.MethodPost, URL: "app://host/path"}, Scheme: "app", TargetID: s.id})
+	wrt.wg.Wait()
+	assert.Len(t, wrt.w, 5)
+	assert.Equal(t, "{\"name\":\""+EventNameProtocolEventRequestCallback+"\",\"targetID\":\"1\",\"callbackId\":\"2\",\"headers\":{\"Content-Type\":[\"text/plain; charset=utf-8\"],\"X-Header\":[\"header\"],\"X-Method\":[\"POST\"]},\"statusCode\":201}\n", wrt.w[0])
+	assert.Equal(t, "{\"name\":\""+EventNameProtocolEventRequestCallbackData+"\",\"targetID\":\"1\",\"bytes\":\"L3BhdGg6Ym9keQ==\",\"callbackId\":\"2\"}\n", wrt.w[1])
+	assert.Equal(t, "{\"name\":\""+EventNameProtocolEventRequestCallbackEnd+"\",\"targetID\":\"1\",\"callbackId\":\"2\"}\n", wrt.w[4])
+
+	// Panic
+	wrt.wg = nil
+	testObjectAction(t, func() error {
+		return s.HandleProtocol("panic", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Set("X-Header", "header")
+			rw.Write([]byte("partial"))
+			panic("handler")
+		}))
+	}, s.object, wrt, "{\"name\":\""+EventNameProtocolCmdHandle+"\",\"targetID\":\"1\",\"scheme\":\"panic\"}\n", EventNameProtocolEventHandled)
+	wrt.fn = nil
+	wrt.w = []string{}
+	wrt.wg = &sync.WaitGroup{}
+	wrt.wg.Add(3)
+	d.dispatch(Event{CallbackID: "4", Name: EventNameProtocolEventRequest, Request: &EventRequest{URL: "panic://host/path"}, Scheme: "panic", TargetID: s.id})
+	wrt.wg.Wait()
+	assert.Len(t, wrt.w, 3)
+	assert.Equal(t, "{\"name\":\""+EventNameProtocolEventRequestCallback+"\",\"targetID\":\"1\",\"callbackId\":\"4\",\"headers\":{\"Content-Type\":[\"text/plain; charset=utf-8\"],\"X-Content-Type-Options\":[\"nosniff\"]},\"statusCode\":500}\n", wrt.w[0])
+
+	// Unhandle
+	wrt.wg = nil
+	testObjectAction(t, func() error { return s.UnhandleProtocol("app") }, s.object, wrt, "{\"name\":\""+EventNameProtocolCmdUnhandle+"\",\"targetID\":\"1\",\"scheme\":\"app\"}\n", EventNameProtocolEventUnhandled)
+
+	// Unhandled scheme
+	wrt.fn = nil
+	wrt.w = []string{}
+	wrt.wg = &sync.WaitGroup{}
+	wrt.wg.Add(3)
+	d.dispatch(Event{CallbackID: "3", Name: EventNameProtocolEventRequest, Request: &EventRequest{URL: "app://host/path"}, Scheme: "app", TargetID: s.id})
+	wrt.wg.Wait()
+	assert.Len(t, wrt.w, 3)
+	assert.Contains(t, wrt.w[0], "\"statusCode\":404")
+}
+
+func TestProtocolHandlers_Schemes(t *testing.T) {
+	// Init
+	d := newDispatcher()
+	s := newSession(context.Background(), &logger{}, d, newIdentifier(), newWriter(&mockedWriter{}, &logger{}))
+	p := s.protocolHandlers
+	handle := func(ctx context.Context, scheme string) *mockedWriter {
+		wrt := &mockedWriter{}
+		wrt.fn = func() { d.dispatch(Event{Name: EventNameProtocolEventHandled, TargetID: "1"}) }
+		assert.NoError(t, p.handle(ctx, newWriter(wrt, &logger{}), scheme, http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.Context().Err() != nil {
+				rw.WriteHeader(http.StatusServiceUnavailable)
+			}
+		})))
+		wrt.fn = nil
+		wrt.w = []string{}
+		wrt.wg = &sync.WaitGroup{}
+		return wrt
+	}
+	wrt1 := handle(context.Background(), "scheme1")
+	ctx, cancel := context.WithCancel(context.Background())
+	wrt2 := handle(ctx, "scheme2")
+	cancel()
+
+	// Requests are served with the context and the writer of their scheme
+	wrt1.wg.Add(2)
+	d.dispatch(Event{CallbackID: "1", Name: EventNameProtocolEventRequest, Request: &EventRequest{URL: "scheme1://host"}, Scheme: "scheme1", TargetID: "1"})
+	wrt1.wg.Wait()
+	assert.Len(t, wrt1.w, 2)
+	assert.Equal(t, "{\"name\":\""+EventNameProtocolEventRequestCallback+"\",\"targetID\":\"1\",\"callbackId\":\"1\",\"statusCode\":200}\n", wrt1.w[0])
+	assert.Len(t, wrt2.w, 0)
+}
+
+func TestProtocolSchemesSwitchValue(t *testing.T) {
+	s, err := protocolSchemesSwitchValue([]ProtocolScheme{{Privileges: &ProtocolSchemePrivileges{Secure: astikit.BoolPtr(true), Standard: astikit.BoolPtr(true)}, Scheme: "app"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "--astilectron-protocol-schemes=[{\"privileges\":{\"secure\":true,\"standard\":true},\"scheme\":\"app\"}]", s)
+}
@@ -77,6 +77,13 @@ func (p *defaultProvisioner) Provision(ctx context.Context, appName, os, arch, v
 	}
 	s.Astilectron = &ProvisionStatusPackage{Version: versionAstilectron}
 
+	// Provision astilectron preload
+	// It's not part of the astilectron package, which is why it's written whatever the provision status
+	if err = ProvisionAstilectronPreload(paths); err != nil {
+		err = fmt.Errorf("provisioning astilectron preload failed: %w", err)
+		return
+	}
+
 	// Provision electron
 	if err = p.provisionElectron(ctx, paths, s, appName, os, arch, versionElectron); err != nil {
 		err = fmt.Errorf("provisioning electron failed: %w", err)
@@ -16,7 +16,10 @@ func testProvisionerSuccessful(t *testing.T, p Paths, osName, arch, versionAstil
 	assert.NoError(t, err)
 	_, err = os.Stat(p.AppExecutable())
 	assert.NoError(t, err)
-	b, err := ioutil.ReadFile(p.ProvisionStatus())
+	b, err := ioutil.ReadFile(p.AstilectronPreload())
+	assert.NoError(t, err)
+	assert.Equal(t, astilectronPreloadScript, string(b))
+	b, err = ioutil.ReadFile(p.ProvisionStatus())
 	assert.NoError(t, err)
 	assert.Equal(t, "{\"astilectron\":{\"version\":\""+versionAstilectron+"\"},\"electron\":{\""+provisionStatusElectronKey(osName, arch)+"\":{\"version\":\""+versionElectron+"\"}}}\n", string(b))
 }
@@ -38,10 +41,11 @@ func TestDefaultProvisioner(t *testing.T) {
 	assert.NoError(t, err)
 	testProvisionerSuccessful(t, *p, "linux", "amd64", DefaultVersionAstilectron, DefaultVersionElectron)
 
-	// Test nothing happens if provision status is up to date
+	// Test nothing happens if provision status is up to date, except for the preload being written
 	mh.e = true
 	os.Remove(p.AstilectronDownloadDst())
 	os.Remove(p.ElectronDownloadDst())
+	os.Remove(p.AstilectronPreload())
 	err = newDefaultProvisioner(nil).Provision(context.Background(), "", "linux", "amd64", DefaultVersionAstilectron, DefaultVersionElectron, *p)
 	assert.NoError(t, err)
 	testProvisionerSuccessful(t, *p, "linux", "amd64", DefaultVersionAstilectron, DefaultVersionElectron)
@@ -0,0 +1,304 @@
+package astilectron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Render process gone reasons
+// https://github.com/electron/electron/blob/v11.4.3/docs/api/web-contents.md#event-render-process-gone
+const (
+	RenderProcessGoneReasonAbnormalExit     = "abnormal-exit"
+	RenderProcessGoneReasonCleanExit        = "clean-exit"
+	RenderProcessGoneReasonCrashed          = "crashed"
+	RenderProcessGoneReasonIntegrityFailure = "integrity-failure"
+	RenderProcessGoneReasonKilled           = "killed"
+	RenderProcessGoneReasonLaunchFailed     = "launch-failed"
+	RenderProcessGoneReasonOOM              = "oom"
+)
+
+// Recovery actions
+const (
+	RecoveryActionErrorPage = "error.page" // The policy's error page is loaded
+	RecoveryActionNone      = "none"
+	RecoveryActionRecreate  = "recreate" // The window is destroyed and a new window is created with the same options and url
+	RecoveryActionReload    = "reload"
+)
+
+// Window failure kinds
+const (
+	WindowFailureKindDidFailLoad       = "did.fail.load"
+	WindowFailureKindRenderProcessGone = "render.process.gone"
+	WindowFailureKindUnresponsive      = "unresponsive"
+)
+
+// Misc
+const (
+	loadFailureErrorCodeAborted  = -3 // The load has been aborted, e.g. because the user navigated somewhere else
+	recoveryDefaultMaxRetries    = 3
+	recoveryDefaultReloadTimeout = 10 * time.Second
+	recoveryDefaultStablePeriod  = 10 * time.Second
+)
+
+// RenderProcessGone represents the details of a renderer process that has disappeared
+type RenderProcessGone struct {
+	ExitCode int    `json:"exitCode"`
+	Reason   string `json:"reason"`
+}
+
+// LoadFailure represents the details of a load that has failed
+// Error codes are Chromium's net error codes
+// https://source.chromium.org/chromium/chromium/src/+/main:net/base/net_error_list.h
+type LoadFailure struct {
+	ErrorCode        int    `json:"errorCode"`
+	ErrorDescription string `json:"errorDescription,omitempty"`
+	IsMainFrame      bool   `json:"isMainFrame,omitempty"`
+	URL              string `json:"validatedURL,omitempty"`
+}
+
+// RecoveryPolicy represents how a window recovers from renderer crashes, main frame load failures and, if
+// UnresponsiveTimeout is set, from being unresponsive for too long
+// Once MaxRetries recoveries have been attempted without the page staying loaded for StablePeriod in between, the
+// window is left as is. A page crashing right after it has finished loading therefore doesn't make the window recover
+// endlessly. MaxRetries defaults to 3 and StablePeriod to 10 seconds.
+// Reloads are given UnresponsiveTimeout, or 10 seconds if it's not set, to complete before failing.
+// OnFailure, if set, is executed before every recovery and can return the action that should be executed instead of
+// Action. OnRecreate, if set, is executed with the new window once the window has been recreated: listeners and
+// handlers must be added to it again, except for the recovery policy which is shared.
+type RecoveryPolicy struct {
+	Action              string
+	ErrorPageURL        string
+	MaxRetries          int
+	OnFailure           func(f WindowFailure) (action string)
+	OnRecreate          func(w *Window)
+	StablePeriod        time.Duration
+	UnresponsiveTimeout time.Duration
+}
+
+// WindowFailure represents a window failure
+// Attempt is the number of the recovery about to be attempted. GaveUp is true when MaxRetries has been reached, in
+// which case no recovery is attempted whatever OnFailure returns.
+type WindowFailure struct {
+	Attempt           int
+	GaveUp            bool
+	Kind              string
+	LoadFailure       *LoadFailure
+	RenderProcessGone *RenderProcessGone
+}
+
+// SetRecoveryPolicy sets how the window recovers from failures
+// A nil policy disables recovery.
+func (w *Window) SetRecoveryPolicy(p *RecoveryPolicy) (err error) {
+	// Check context
+	if err = w.ctx.Err(); err != nil {
+		return
+	}
+
+	// Validate policy
+	var c *RecoveryPolicy
+	if p != nil {
+		if p.Action == RecoveryActionErrorPage && p.ErrorPageURL == "" {
+			err = errors.New("error page url is missing")
+			return
+		}
+		c = &RecoveryPolicy{}
+		*c = *p
+		if c.MaxRetries <= 0 {
+			c.MaxRetries = recoveryDefaultMaxRetries
+		}
+		if c.StablePeriod <= 0 {
+			c.StablePeriod = recoveryDefaultStablePeriod
+		}
+	}
+
+	// Store policy
+	w.m.Lock()
+	w.recoveryPolicy = c
+	listening := w.recoveryListening
+	w.recoveryListening = true
+	if c == nil {
+		w.stopRecoveryTimer()
+	}
+	w.m.Unlock()
+
+	// Listen, counting failures and loads in the order they were sent by Electron. Recoveries wait for Electron's
+	// answers, which is why they're executed in their own goroutine.
+	if !listening {
+		w.d.setOrderedHandler(w.id, EventNameWindowEventRenderProcessGone, func(e Event) {
+			if p, f, ok := w.countFailure(WindowFailure{Kind: WindowFailureKindRenderProcessGone, RenderProcessGone: e.RenderProcessGone}); ok {
+				go w.handleFailure(p, f)
+			}
+		})
+		w.d.setOrderedHandler(w.id, EventNameWindowEventDidFailLoad, func(e Event) {
+			// Only main frame failures that have not been aborted need to be recovered from
+			if e.LoadFailure == nil || !e.LoadFailure.IsMainFrame || e.LoadFailure.ErrorCode == loadFailureErrorCodeAborted {
+				return
+			}
+			if p, f, ok := w.countFailure(WindowFailure{Kind: WindowFailureKindDidFailLoad, LoadFailure: e.LoadFailure}); ok {
+				go w.handleFailure(p, f)
+			}
+		})
+		w.d.setOrderedHandler(w.id, EventNameWindowEventUnresponsive, func(e Event) {
+			w.m.Lock()
+			defer w.m.Unlock()
+			if w.recoveryPolicy == nil || w.recoveryPolicy.UnresponsiveTimeout <= 0 || w.recoveryTimer != nil {
+				return
+			}
+			w.recoveryTimer = time.AfterFunc(w.recoveryPolicy.UnresponsiveTimeout, func() {
+				w.m.Lock()
+				w.recoveryTimer = nil
+				w.m.Unlock()
+				if p, f, ok := w.countFailure(WindowFailure{Kind: WindowFailureKindUnresponsive}); ok {
+					w.handleFailure(p, f)
+				}
+			})
+		})
+		w.d.setOrderedHandler(w.id, EventNameWindowEventResponsive, func(e Event) {
+			w.m.Lock()
+			defer w.m.Unlock()
+			w.stopRecoveryTimer()
+		})
+		w.d.setOrderedHandler(w.id, EventNameWindowEventDidFinishLoad, func(e Event) {
+			w.m.Lock()
+			defer w.m.Unlock()
+			w.recoveryLoadedAt = time.Now()
+		})
+		w.On(EventNameWindowEventClosed, func(e Event) (deleteListener bool) {
+			w.m.Lock()
+			defer w.m.Unlock()
+			w.stopRecoveryTimer()
+			return true
+		})
+	}
+	return
+}
+
+// stopRecoveryTimer stops the unresponsive timer if any
+// It assumes the mutex is locked
+func (w *Window) stopRecoveryTimer() {
+	if w.recoveryTimer != nil {
+		w.recoveryTimer.Stop()
+		w.recoveryTimer = nil
+	}
+}
+
+// countFailure counts a failure against the recovery policy's retries and returns the policy
+// ok is false if recovery is disabled.
+func (w *Window) countFailure(i WindowFailure) (p *RecoveryPolicy, f WindowFailure, ok bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if p = w.recoveryPolicy; p == nil {
+		return
+	}
+	f = i
+	ok = true
+	if !w.recoveryLoadedAt.IsZero() && time.Since(w.recoveryLoadedAt) >= p.StablePeriod {
+		w.recoveryAttempts = 0
+	}
+	w.recoveryLoadedAt = time.Time{}
+	if w.recoveryAttempts >= p.MaxRetries {
+		f.GaveUp = true
+	} else {
+		w.recoveryAttempts++
+		f.Attempt = w.recoveryAttempts
+	}
+	return
+}
+
+// handleFailure lets the recovery policy decide how the window recovers from a counted failure and executes the
+// recovery
+func (w *Window) handleFailure(p *RecoveryPolicy, f WindowFailure) {
+	// Decide
+	action := p.Action
+	if p.OnFailure != nil {
+		if a := p.OnFailure(f); a != "" {
+			action = a
+		}
+	}
+
+	// Gave up
+	if f.GaveUp {
+		w.l.Errorf("Giving up recovering window %s from %s after %d attempts", w.id, f.Kind, p.MaxRetries)
+		return
+	}
+
+	// Recover
+	if err := w.recover(action, p, f.Attempt); err != nil {
+		w.l.Error(fmt.Errorf("recovering window %s from %s with action %s failed: %w", w.id, f.Kind, action, err))
+	}
+}
+
+// recover executes a recovery action
+func (w *Window) recover(action string, p *RecoveryPolicy, attempts int) (err error) {
+	switch action {
+	case RecoveryActionErrorPage:
+		if p.ErrorPageURL == "" {
+			return errors.New("error page url is missing")
+		}
+		return w.LoadURL(p.ErrorPageURL)
+	case RecoveryActionRecreate:
+		return w.recreate(p, attempts)
+	case RecoveryActionReload:
+		t := p.UnresponsiveTimeout
+		if t <= 0 {
+			t = recoveryDefaultReloadTimeout
+		}
+		ctx, cancel := context.WithTimeout(w.ctx, t)
+		defer cancel()
+		return w.Reload(ctx)
+	case RecoveryActionNone, "":
+		return
+	}
+	return fmt.Errorf("unknown action %s", action)
+}
+
+// recreate destroys the window and creates a new window with the same options and url
+func (w *Window) recreate(p *RecoveryPolicy, attempts int) (err error) {
+	// No way to create windows
+	if w.newWindow == nil {
+		err = fmt.Errorf("window %s can't create windows", w.id)
+		return
+	}
+
+	// Copy options
+	o := &WindowOptions{}
+	w.m.Lock()
+	*o = *w.o
+	w.m.Unlock()
+
+	// Create new window
+	var n *Window
+	if n, err = w.newWindow(w.rawURL, o); err != nil {
+		err = fmt.Errorf("creating new window failed: %w", err)
+		return
+	}
+
+	// Share recovery policy
+	if err = n.SetRecoveryPolicy(p); err != nil {
+		err = fmt.Errorf("setting recovery policy failed: %w", err)
+		return
+	}
+	n.m.Lock()
+	n.recoveryAttempts = attempts
+	n.m.Unlock()
+
+	// Destroy window
+	if err = w.Destroy(); err != nil {
+		err = fmt.Errorf("destroying window failed: %w", err)
+		return
+	}
+
+	// Create window
+	if err = n.Create(); err != nil {
+		err = fmt.Errorf("creating window failed: %w", err)
+		return
+	}
+
+	// Callback
+	if p.OnRecreate != nil {
+		p.OnRecreate(n)
+	}
+	return
+}
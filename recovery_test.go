@@ -0,0 +1,179 @@
+package astilectron
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astikit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindow_SetRecoveryPolicy(t *testing.T) {
+	// Init
+	a, err := New(nil, Options{})
+	assert.NoError(t, err)
+	defer a.Close()
+	wrt := &mockedWriter{wg: &sync.WaitGroup{}}
+	a.writer = newWriter(wrt, &logger{})
+	w, err := a.NewWindow("http://test.com", &WindowOptions{})
+	assert.NoError(t, err)
+	wrt.fn = func() {
+		var e Event
+		json.Unmarshal([]byte(wrt.w[len(wrt.w)-1]), &e)
+		switch e.Name {
+		case EventNameWindowCmdWebContentsReload:
+			a.dispatcher.dispatch(Event{CallbackID: e.CallbackID, Name: EventNameWindowEventWebContentsReloaded, TargetID: w.id})
+		case EventNameWindowCmdLoadURL:
+			a.dispatcher.dispatch(Event{Name: EventNameWindowLoadedURL, TargetID: w.id})
+		}
+	}
+
+	// Invalid policy
+	assert.Error(t, w.SetRecoveryPolicy(&RecoveryPolicy{Action: RecoveryActionErrorPage}))
+
+	// Set policy
+	var m sync.Mutex
+	var action string
+	var failures []WindowFailure
+	lastFailure := func() WindowFailure {
+		m.Lock()
+		defer m.Unlock()
+		return failures[len(failures)-1]
+	}
+	assert.NoError(t, w.SetRecoveryPolicy(&RecoveryPolicy{
+		Action:       RecoveryActionReload,
+		ErrorPageURL: "app://error",
+		MaxRetries:   2,
+		OnFailure: func(f WindowFailure) string {
+			m.Lock()
+			defer m.Unlock()
+			failures = append(failures, f)
+			return action
+		},
+	}))
+
+	// Render process gone
+	wrt.wg.Add(1)
+	a.dispatcher.dispatch(Event{Name: EventNameWindowEventRenderProcessGone, RenderProcessGone: &RenderProcessGone{ExitCode: 1, Reason: RenderProcessGoneReasonCrashed}, TargetID: w.id})
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameWindowCmdWebContentsReload + "\",\"targetID\":\"" + w.id + "\",\"callbackId\":\"3\"}\n"}, wrt.w)
+	assert.Equal(t, WindowFailure{Attempt: 1, Kind: WindowFailureKindRenderProcessGone, RenderProcessGone: &RenderProcessGone{ExitCode: 1, Reason: RenderProcessGoneReasonCrashed}}, lastFailure())
+
+	// Sub frame and aborted load failures are ignored
+	a.dispatcher.dispatch(Event{Name: EventNameWindowEventDidFailLoad, LoadFailure: &LoadFailure{ErrorCode: -105, URL: "http://frame.com"}, TargetID: w.id})
+	a.dispatcher.dispatch(Event{Name: EventNameWindowEventDidFailLoad, LoadFailure: &LoadFailure{ErrorCode: loadFailureErrorCodeAborted, IsMainFrame: true, URL: "http://test.com"}, TargetID: w.id})
+
+	// Main frame load failure
+	wrt.w = []string{}
+	wrt.wg.Add(1)
+	l := &LoadFailure{ErrorCode: -105, ErrorDescription: "ERR_NAME_NOT_RESOLVED", IsMainFrame: true, URL: "http://test.com"}
+	a.dispatcher.dispatch(Event{Name: EventNameWindowEventDidFailLoad, LoadFailure: l, TargetID: w.id})
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameWindowCmdWebContentsReload + "\",\"targetID\":\"" + w.id + "\",\"callbackId\":\"4\"}\n"}, wrt.w)
+	assert.Equal(t, WindowFailure{Attempt: 2, Kind: WindowFailureKindDidFailLoad, LoadFailure: l}, lastFailure())
+
+	// Max retries
+	wrt.w = []string{}
+	a.dispatcher.dispatch(Event{Name: EventNameWindowEventRenderProcessGone, RenderProcessGone: &RenderProcessGone{Reason: RenderProcessGoneReasonOOM}, TargetID: w.id})
+	testEventually(t, func() bool { return lastFailure().GaveUp })
+	assert.Equal(t, WindowFailure{GaveUp: true, Kind: WindowFailureKindRenderProcessGone, RenderProcessGone: &RenderProcessGone{Reason: RenderProcessGoneReasonOOM}}, lastFailure())
+	assert.Equal(t, []string{}, wrt.w)
+	m.Lock()
+	assert.Len(t, failures, 3)
+	m.Unlock()
+
+	// Attempts are not reset if the page crashes right after it has loaded
+	a.dispatcher.dispatch(Event{Name: EventNameWindowEventDidFinishLoad, TargetID: w.id})
+	a.dispatcher.dispatch(Event{Name: EventNameWindowEventRenderProcessGone, RenderProcessGone: &RenderProcessGone{Reason: RenderProcessGoneReasonCrashed}, TargetID: w.id})
+	testEventually(t, func() bool {
+		m.Lock()
+		defer m.Unlock()
+		return len(failures) == 4
+	})
+	assert.True(t, lastFailure().GaveUp)
+	assert.Equal(t, []string{}, wrt.w)
+
+	// Attempts are reset once the page has stayed loaded for the stable period
+	a.dispatcher.dispatch(Event{Name: EventNameWindowEventDidFinishLoad, TargetID: w.id})
+	w.m.Lock()
+	w.recoveryLoadedAt = w.recoveryLoadedAt.Add(-recoveryDefaultStablePeriod)
+	w.m.Unlock()
+
+	// Action is overridden
+	m.Lock()
+	action = RecoveryActionErrorPage
+	m.Unlock()
+	wrt.wg.Add(1)
+	a.dispatcher.dispatch(Event{Name: EventNameWindowEventRenderProcessGone, RenderProcessGone: &RenderProcessGone{Reason: RenderProcessGoneReasonKilled}, TargetID: w.id})
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameWindowCmdLoadURL + "\",\"targetID\":\"" + w.id + "\",\"url\":\"app://error\"}\n"}, wrt.w)
+	assert.Equal(t, 1, lastFailure().Attempt)
+
+	// Unresponsive
+	m.Lock()
+	action = RecoveryActionNone
+	m.Unlock()
+	assert.NoError(t, w.SetRecoveryPolicy(&RecoveryPolicy{
+		OnFailure: func(f WindowFailure) string {
+			m.Lock()
+			defer m.Unlock()
+			failures = append(failures, f)
+			return action
+		},
+		UnresponsiveTimeout: time.Millisecond,
+	}))
+	a.dispatcher.dispatch(Event{Name: EventNameWindowEventUnresponsive, TargetID: w.id})
+	testEventually(t, func() bool { return lastFailure().Kind == WindowFailureKindUnresponsive })
+
+	// Reloads of a hung renderer time out
+	wrt.fn = nil
+	wrt.wg.Add(1)
+	assert.Error(t, w.recover(RecoveryActionReload, &RecoveryPolicy{UnresponsiveTimeout: time.Millisecond}, 1))
+}
+
+func TestWindow_RecoveryRecreate(t *testing.T) {
+	// Init
+	a, err := New(nil, Options{})
+	assert.NoError(t, err)
+	defer a.Close()
+	wrt := &mockedWriter{}
+	a.writer = newWriter(wrt, &logger{})
+	w, err := a.NewWindow("http://test.com", &WindowOptions{Width: astikit.IntPtr(100)})
+	assert.NoError(t, err)
+	wrt.fn = func() {
+		var e Event
+		json.Unmarshal([]byte(wrt.w[len(wrt.w)-1]), &e)
+		switch e.Name {
+		case EventNameWindowCmdCreate:
+			a.dispatcher.dispatch(Event{Name: EventNameWindowEventDidFinishLoad, TargetID: e.TargetID})
+		case EventNameWindowCmdDestroy:
+			a.dispatcher.dispatch(Event{Name: EventNameWindowEventClosed, TargetID: e.TargetID})
+		}
+	}
+
+	// Recreate
+	var m sync.Mutex
+	var n *Window
+	assert.NoError(t, w.SetRecoveryPolicy(&RecoveryPolicy{
+		Action: RecoveryActionRecreate,
+		OnRecreate: func(w *Window) {
+			m.Lock()
+			defer m.Unlock()
+			n = w
+		},
+	}))
+	a.dispatcher.dispatch(Event{Name: EventNameWindowEventRenderProcessGone, RenderProcessGone: &RenderProcessGone{Reason: RenderProcessGoneReasonCrashed}, TargetID: w.id})
+	testEventually(t, func() bool {
+		m.Lock()
+		defer m.Unlock()
+		return n != nil
+	})
+	assert.Error(t, w.ctx.Err())
+	assert.NotEqual(t, w.id, n.id)
+	assert.Equal(t, w.o, n.o)
+	assert.False(t, w.o == n.o)
+	assert.Equal(t, 1, n.recoveryAttempts)
+	wrt.fn = nil
+}
@@ -2,49 +2,126 @@ package astilectron
 
 import (
 	"context"
+	"net/http"
+	"sync"
+
+	"github.com/asticode/go-astikit"
 )
 
 // Session event names
 const (
-	EventNameSessionCmdClearCache                          = "session.cmd.clear.cache"
-	EventNameSessionEventClearedCache                      = "session.event.cleared.cache"
-	EventNameSessionCmdFlushStorage                        = "session.cmd.flush.storage"
-	EventNameSessionEventFlushedStorage                    = "session.event.flushed.storage"
-	EventNameSessionCmdLoadExtension                       = "session.cmd.load.extension"
-	EventNameSessionEventLoadedExtension                   = "session.event.loaded.extension"
-	EventNameSessionEventWillDownload                      = "session.event.will.download"
-	EventNameSessionCmdSetCookies                          = "session.cmd.cookies.set"
-	EventNameSessionEventSetCookies                        = "session.event.cookies.set"
-	EventNameSessionCmdGetCookies                          = "session.cmd.cookies.get"
-	EventNameSessionEventGetCookies                        = "session.event.cookies.get"
-	EventNameSessionCmdFromPartition                       = "session.cmd.from.partition"
-	EventNameSessionEventFromPartition                     = "session.event.from.partition"
-	EventNameSessionCmdSetUserAgent                        = "session.cmd.set.user.agent"
-	EventNameSessionEventSetUserAgent                      = "session.event.set.user.agent"
-	EventNameSessionCmdCloseAllConnections                 = "session.cmd.close.all.connections"
-	EventNameSessionEventCloseAllConnections               = "session.event.close.all.connections"
-	EventNameSessionCmdSetProxy                            = "session.cmd.set.proxy"
-	EventNameSessionEventSetProxy                          = "session.event.set.proxy"
-	EventNameSessionCmdWebRequestOnBeforeRequest           = "session.cmd.web.request.on.before.request"
-	EventNameSessionEventWebRequestOnBeforeRequest         = "session.event.web.request.on.before.request"
-	EventNameSessionEventWebRequestOnBeforeRequestCallback = "session.event.web.request.on.before.request.callback"
+	EventNameSessionCmdClearCache                              = "session.cmd.clear.cache"
+	EventNameSessionEventClearedCache                          = "session.event.cleared.cache"
+	EventNameSessionCmdFlushStorage                            = "session.cmd.flush.storage"
+	EventNameSessionEventFlushedStorage                        = "session.event.flushed.storage"
+	EventNameSessionCmdClearStorageData                        = "session.cmd.clear.storage.data"
+	EventNameSessionEventClearedStorageData                    = "session.event.cleared.storage.data"
+	EventNameSessionCmdClearAuthCache                          = "session.cmd.clear.auth.cache"
+	EventNameSessionEventClearedAuthCache                      = "session.event.cleared.auth.cache"
+	EventNameSessionCmdGetCacheSize                            = "session.cmd.get.cache.size"
+	EventNameSessionEventGetCacheSize                          = "session.event.get.cache.size"
+	EventNameSessionCmdLoadExtension                           = "session.cmd.load.extension"
+	EventNameSessionEventLoadedExtension                       = "session.event.loaded.extension"
+	EventNameSessionEventWillDownload                          = "session.event.will.download"
+	EventNameSessionCmdSetCookies                              = "session.cmd.cookies.set"
+	EventNameSessionEventSetCookies                            = "session.event.cookies.set"
+	EventNameSessionCmdGetCookies                              = "session.cmd.cookies.get"
+	EventNameSessionEventGetCookies                            = "session.event.cookies.get"
+	EventNameSessionCmdFromPartition                           = "session.cmd.from.partition"
+	EventNameSessionEventFromPartition                         = "session.event.from.partition"
+	EventNameSessionCmdSetUserAgent                            = "session.cmd.set.user.agent"
+	EventNameSessionEventSetUserAgent                          = "session.event.set.user.agent"
+	EventNameSessionCmdCloseAllConnections                     = "session.cmd.close.all.connections"
+	EventNameSessionEventCloseAllConnections                   = "session.event.close.all.connections"
+	EventNameSessionCmdSetProxy                                = "session.cmd.set.proxy"
+	EventNameSessionEventSetProxy                              = "session.event.set.proxy"
+	EventNameSessionCmdWebRequestOnBeforeRequest               = "session.cmd.web.request.on.before.request"
+	EventNameSessionEventWebRequestOnBeforeRequest             = "session.event.web.request.on.before.request"
+	EventNameSessionEventWebRequestOnBeforeRequestCallback     = "session.event.web.request.on.before.request.callback"
+	EventNameSessionCmdWebRequestOnBeforeSendHeaders           = "session.cmd.web.request.on.before.send.headers"
+	EventNameSessionEventWebRequestOnBeforeSendHeaders         = "session.event.web.request.on.before.send.headers"
+	EventNameSessionEventWebRequestOnBeforeSendHeadersCallback = "session.event.web.request.on.before.send.headers.callback"
+	EventNameSessionCmdWebRequestOnHeadersReceived             = "session.cmd.web.request.on.headers.received"
+	EventNameSessionEventWebRequestOnHeadersReceived           = "session.event.web.request.on.headers.received"
+	EventNameSessionEventWebRequestOnHeadersReceivedCallback   = "session.event.web.request.on.headers.received.callback"
+	EventNameSessionCmdWebRequestOnResponseStarted             = "session.cmd.web.request.on.response.started"
+	EventNameSessionEventWebRequestOnResponseStarted           = "session.event.web.request.on.response.started"
+	EventNameSessionCmdWebRequestOnCompleted                   = "session.cmd.web.request.on.completed"
+	EventNameSessionEventWebRequestOnCompleted                 = "session.event.web.request.on.completed"
+	EventNameSessionCmdWebRequestOnErrorOccurred               = "session.cmd.web.request.on.error.occurred"
+	EventNameSessionEventWebRequestOnErrorOccurred             = "session.event.web.request.on.error.occurred"
+)
+
+// Session storages
+const (
+	SessionStorageAppcache       = "appcache"
+	SessionStorageCacheStorage   = "cachestorage"
+	SessionStorageCookies        = "cookies"
+	SessionStorageFilesystem     = "filesystem"
+	SessionStorageIndexDB        = "indexdb"
+	SessionStorageLocalStorage   = "localstorage"
+	SessionStorageServiceWorkers = "serviceworkers"
+	SessionStorageShaderCache    = "shadercache"
+	SessionStorageWebSQL         = "websql"
+)
+
+// Session storage quotas
+const (
+	SessionStorageQuotaPersistent = "persistent"
+	SessionStorageQuotaSyncable   = "syncable"
+	SessionStorageQuotaTemporary  = "temporary"
 )
 
+// ClearStorageDataOptions represents clear storage data options
+// Empty Storages or Quotas means all of them are cleared
+// https://github.com/electron/electron/blob/v11.4.3/docs/api/session.md#sesclearstoragedataoptions
+type ClearStorageDataOptions struct {
+	Origin   string   `json:"origin,omitempty"`
+	Quotas   []string `json:"quotas,omitempty"`
+	Storages []string `json:"storages,omitempty"`
+}
+
 // Session represents a session
 // TODO Add missing session methods
 // TODO Add missing session events
 // https://github.com/electron/electron/blob/v1.8.1/docs/api/session.md
 type Session struct {
 	*object
-	ID string
+	ID                         string
+	certificateVerifyListening bool
+	certificateVerifyProc      func(r CertificateVerifyRequest) int
+	cookieChangesForwarded     bool
+	downloads                  []*DownloadItem
+	l                          astikit.SeverityLogger
+	m                          sync.Mutex // Locks certificateVerifyListening, certificateVerifyProc, cookieChangesForwarded, downloads, navigationPolicy, navigationPolicyHandled, navigationRequests, partition, permissionRequest and willDownload
+	mocks                      *sessionMocks
+	navigationPolicy           *navigationPolicy
+	navigationPolicyHandled    bool
+	navigationRequests         map[int]bool // Frame requests that haven't completed yet, indexed by id
+	partition                  string
+	permissionRequest          permissionHandler
+	protocolHandlers           *protocolHandlers
+	webRequest                 *WebRequestRouter
+	willDownload               []func(d *DownloadItem)
 }
 
 // newSession creates a new session
-func newSession(ctx context.Context, d *dispatcher, i *identifier, w *writer) *Session {
+func newSession(ctx context.Context, l astikit.SeverityLogger, d *dispatcher, i *identifier, w *writer) *Session {
 	id := i.new()
-	s := &Session{object: newObject(ctx, d, i, w, id)}
+	s := &Session{
+		l:                  l,
+		navigationRequests: make(map[int]bool),
+		object:             newObject(ctx, d, i, w, id),
+	}
 
 	s.ID = id
+	s.protocolHandlers = newProtocolHandlers(l, s, s.id)
+	s.mocks = newSessionMocks(s)
+	s.webRequest = newWebRequestRouter(s)
+	s.On(EventNameSessionEventWillDownload, func(e Event) (deleteListener bool) {
+		s.handleWillDownload(e)
+		return
+	})
 
 	return s
 }
@@ -58,6 +135,42 @@ func (s *Session) ClearCache() (err error) {
 	return
 }
 
+// ClearStorageData clears the session's storage data
+func (s *Session) ClearStorageData(ctx context.Context, o ClearStorageDataOptions) (err error) {
+	_, err = synchronousRequest(ctx, s.object, Event{ClearStorageDataOptions: &o, Name: EventNameSessionCmdClearStorageData, TargetID: s.id}, EventNameSessionEventClearedStorageData)
+	return
+}
+
+// ClearAuthCache clears the session's HTTP authentication cache
+func (s *Session) ClearAuthCache(ctx context.Context) (err error) {
+	_, err = synchronousRequest(ctx, s.object, Event{Name: EventNameSessionCmdClearAuthCache, TargetID: s.id}, EventNameSessionEventClearedAuthCache)
+	return
+}
+
+// GetCacheSize returns the session's current cache size, in bytes
+func (s *Session) GetCacheSize(ctx context.Context) (size int, err error) {
+	var e Event
+	if e, err = synchronousRequest(ctx, s.object, Event{Name: EventNameSessionCmdGetCacheSize, TargetID: s.id}, EventNameSessionEventGetCacheSize); err != nil {
+		return
+	}
+	if e.CacheSize != nil {
+		size = *e.CacheSize
+	}
+	return
+}
+
+// HandleProtocol serves a custom scheme in the session with an http.Handler
+// Requests are forwarded with their method, headers and upload body, and responses are streamed back in chunks.
+// Use Options.ProtocolSchemes to register the scheme as privileged.
+func (s *Session) HandleProtocol(scheme string, h http.Handler) error {
+	return s.protocolHandlers.handle(s.ctx, s.w, scheme, h)
+}
+
+// UnhandleProtocol stops serving a custom scheme in the session
+func (s *Session) UnhandleProtocol(scheme string) error {
+	return s.protocolHandlers.unhandle(s.ctx, s.w, scheme)
+}
+
 // FlushStorage writes any unwritten DOMStorage data to disk
 func (s *Session) FlushStorage() (err error) {
 	if err = s.ctx.Err(); err != nil {
@@ -76,24 +189,17 @@ func (s *Session) LoadExtension(path string) (err error) {
 	return
 }
 
+// OnBeforeRequest lets Go cancel or redirect requests matching the filter before they're made
+// It adds a handler to the session's web request router, which is removed when fn returns deleteListener = true
 func (s *Session) OnBeforeRequest(filter FilterOptions, fn func(i Event) (cancel bool, redirectUrl string, deleteListener bool)) (err error) {
-	// Setup the event to handle the callback
-	s.On(EventNameSessionEventWebRequestOnBeforeRequest, func(i Event) (deleteListener bool) {
-		cancel, redirectUrl, deleteListener := fn(i)
-
-		// Send message back
-		if err = s.w.write(Event{CallbackID: i.CallbackID, Name: EventNameSessionEventWebRequestOnBeforeRequestCallback, TargetID: s.id, Cancel: &cancel, RedirectURL: redirectUrl}); err != nil {
-			return
+	_, err = s.webRequest.add(EventNameSessionEventWebRequestOnBeforeRequest, WebRequestHandlerOptions{Patterns: filter.Urls}, func(e Event, d WebRequestDetails, dc *webRequestDecision) (deleteHandler bool) {
+		var redirectURL string
+		dc.cancel, redirectURL, deleteHandler = fn(e)
+		if dc.redirectURL == "" {
+			dc.redirectURL = redirectURL
 		}
-
 		return
 	})
-
-	if err = s.ctx.Err(); err != nil {
-		return
-	}
-
-	s.w.write(Event{Name: EventNameSessionCmdWebRequestOnBeforeRequest, TargetID: s.id, Filter: &filter})
 	return
 }
 
@@ -111,13 +217,11 @@ type SessionCookie struct {
 }
 
 func (s *Session) SetCookies(cookies []SessionCookie) (err error) {
-	if err = s.ctx.Err(); err != nil {
-		return
-	}
-	_, err = synchronousEvent(s.ctx, s, s.w, Event{Name: EventNameSessionCmdSetCookies, TargetID: s.id, Cookies: cookies}, EventNameSessionEventSetCookies)
-	return
+	return s.setCookies(context.Background(), cookies)
 }
 
+// GetCookies returns an event containing all the session's cookies
+// Deprecated: use Cookies instead
 func (s *Session) GetCookies() (e Event, err error) {
 	if err = s.ctx.Err(); err != nil {
 		return
@@ -126,15 +230,29 @@ func (s *Session) GetCookies() (e Event, err error) {
 	return
 }
 
+// FromPartition binds the session to a partition
+// Use Astilectron.SessionFromPartition to get a session shared by every caller using the same partition
 func (s *Session) FromPartition(partition string) (err error) {
 	if err = s.ctx.Err(); err != nil {
 		return
 	}
 
-	_, err = synchronousEvent(s.ctx, s, s.w, Event{Name: EventNameSessionCmdFromPartition, SessionID: s.id, Partition: partition}, EventNameSessionEventFromPartition)
+	if _, err = synchronousEvent(s.ctx, s, s.w, Event{Name: EventNameSessionCmdFromPartition, SessionID: s.id, Partition: partition}, EventNameSessionEventFromPartition); err != nil {
+		return
+	}
+	s.m.Lock()
+	s.partition = partition
+	s.m.Unlock()
 	return
 }
 
+// Partition returns the partition the session is bound to, if any
+func (s *Session) Partition() string {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.partition
+}
+
 func (s *Session) SetUserAgent(userAgent string, acceptLanguages string) (err error) {
 	if err = s.ctx.Err(); err != nil {
 		return
@@ -161,3 +279,13 @@ func (s *Session) SetProxy(windowProxyOptions WindowProxyOptions) (err error) {
 	_, err = synchronousEvent(s.ctx, s, s.w, Event{Name: EventNameSessionCmdSetProxy, TargetID: s.id, Proxy: &windowProxyOptions}, EventNameSessionEventSetProxy)
 	return
 }
+
+// useSession makes sure the web contents created with the window options use the options' session
+func useSession(wo *WindowOptions) {
+	if wo.WebPreferences == nil {
+		wo.WebPreferences = &WebPreferences{}
+	}
+	if wo.WebPreferences.Session == nil {
+		wo.WebPreferences.Session = astikit.StrPtr(wo.Session.ID)
+	}
+}
@@ -0,0 +1,110 @@
+package astilectron
+
+import "context"
+
+// Session cookie event names
+const (
+	EventNameSessionCmdCookiesFlush     = "session.cmd.cookies.flush"
+	EventNameSessionCmdCookiesOnChanged = "session.cmd.cookies.on.changed"
+	EventNameSessionCmdCookiesRemove    = "session.cmd.cookies.remove"
+	EventNameSessionEventCookiesChanged = "session.event.cookies.changed"
+	EventNameSessionEventCookiesFlushed = "session.event.cookies.flushed"
+	EventNameSessionEventCookiesRemoved = "session.event.cookies.removed"
+)
+
+// Session cookie change causes
+const (
+	SessionCookieChangeCauseEvicted          = "evicted"
+	SessionCookieChangeCauseExpired          = "expired"
+	SessionCookieChangeCauseExpiredOverwrite = "expired-overwrite"
+	SessionCookieChangeCauseExplicit         = "explicit"
+	SessionCookieChangeCauseOverwrite        = "overwrite"
+)
+
+// Session cookie same site values
+const (
+	SessionCookieSameSiteLax           = "lax"
+	SessionCookieSameSiteNoRestriction = "no_restriction"
+	SessionCookieSameSiteStrict        = "strict"
+	SessionCookieSameSiteUnspecified   = "unspecified"
+)
+
+// SessionCookieFilter represents a filter used to retrieve cookies
+// Empty attributes are ignored. We must use pointers since GO doesn't handle optional fields whereas NodeJS does.
+// https://github.com/electron/electron/blob/v11.4.3/docs/api/cookies.md#cookiesgetfilter
+type SessionCookieFilter struct {
+	Domain  string `json:"domain,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Secure  *bool  `json:"secure,omitempty"`
+	Session *bool  `json:"session,omitempty"`
+	URL     string `json:"url,omitempty"`
+}
+
+// SessionCookieChange represents a cookie change
+// https://github.com/electron/electron/blob/v11.4.3/docs/api/cookies.md#event-changed
+type SessionCookieChange struct {
+	Cause   string        `json:"cause,omitempty"`
+	Cookie  SessionCookie `json:"cookie"`
+	Removed bool          `json:"removed,omitempty"`
+}
+
+// Cookies returns the session's cookies matching the filter
+func (s *Session) Cookies(ctx context.Context, filter SessionCookieFilter) (cs []SessionCookie, err error) {
+	var e Event
+	if e, err = synchronousRequest(ctx, s.object, Event{CookieFilter: &filter, Name: EventNameSessionCmdGetCookies, TargetID: s.id}, EventNameSessionEventGetCookies); err != nil {
+		return
+	}
+	cs = e.Cookies
+	return
+}
+
+// setCookies sets cookies
+// A callback id is used so that concurrent calls don't receive each other's answers.
+func (s *Session) setCookies(ctx context.Context, cookies []SessionCookie) (err error) {
+	_, err = synchronousRequest(ctx, s.object, Event{Cookies: cookies, Name: EventNameSessionCmdSetCookies, TargetID: s.id}, EventNameSessionEventSetCookies)
+	return
+}
+
+// RemoveCookie removes the cookie matching the url and name
+func (s *Session) RemoveCookie(url, name string) (err error) {
+	return s.removeCookie(context.Background(), url, name)
+}
+
+// removeCookie removes the cookie matching the url and name
+func (s *Session) removeCookie(ctx context.Context, url, name string) (err error) {
+	_, err = synchronousRequest(ctx, s.object, Event{Cookies: []SessionCookie{{Name: name, Url: url}}, Name: EventNameSessionCmdCookiesRemove, TargetID: s.id}, EventNameSessionEventCookiesRemoved)
+	return
+}
+
+// FlushCookies writes any unwritten cookies data to disk
+func (s *Session) FlushCookies() (err error) {
+	_, err = synchronousRequest(context.Background(), s.object, Event{Name: EventNameSessionCmdCookiesFlush, TargetID: s.id}, EventNameSessionEventCookiesFlushed)
+	return
+}
+
+// OnCookieChanged executes a callback when a cookie is added, edited, removed or expires
+func (s *Session) OnCookieChanged(fn func(c SessionCookieChange)) (err error) {
+	// Check context
+	if err = s.ctx.Err(); err != nil {
+		return
+	}
+
+	// Add listener
+	s.On(EventNameSessionEventCookiesChanged, func(e Event) (deleteListener bool) {
+		if e.CookieChange != nil {
+			fn(*e.CookieChange)
+		}
+		return
+	})
+
+	// Let Electron know it should forward changes, only once
+	s.m.Lock()
+	if s.cookieChangesForwarded {
+		s.m.Unlock()
+		return
+	}
+	s.cookieChangesForwarded = true
+	s.m.Unlock()
+	return s.w.write(Event{Name: EventNameSessionCmdCookiesOnChanged, TargetID: s.id})
+}
@@ -0,0 +1,171 @@
+package astilectron
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/asticode/go-astikit"
+)
+
+// Session cookie jar misc
+const (
+	sessionCookieJarDefaultPath = "/" // Used when the request url doesn't provide a path
+	sessionCookieJarTimeout     = 10 * time.Second
+)
+
+// SessionCookieJar represents an http.CookieJar backed by a session's cookies
+// It allows GO HTTP clients to share cookies with the session in both directions: requests are sent with the session's
+// cookies, and cookies received by GO HTTP clients are pushed into the session.
+// Since the http.CookieJar interface can't return errors, they're logged. Since it can't be given a context either,
+// Electron is given 10 seconds to answer so that HTTP clients can't be blocked forever.
+type SessionCookieJar struct {
+	s *Session
+}
+
+// CookieJar returns an http.CookieJar backed by the session's cookies
+func (s *Session) CookieJar() *SessionCookieJar {
+	return &SessionCookieJar{s: s}
+}
+
+// Cookies implements the http.CookieJar interface
+func (j *SessionCookieJar) Cookies(u *url.URL) (cs []*http.Cookie) {
+	// Only http and https are supported
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return
+	}
+
+	// Get cookies
+	ctx, cancel := context.WithTimeout(context.Background(), sessionCookieJarTimeout)
+	defer cancel()
+	scs, err := j.s.Cookies(ctx, SessionCookieFilter{URL: u.String()})
+	if err != nil {
+		j.s.l.Error(fmt.Errorf("getting cookies of %s failed: %w", u, err))
+		return
+	}
+
+	// Convert
+	for _, sc := range scs {
+		cs = append(cs, sessionCookieToHTTP(sc))
+	}
+	return
+}
+
+// SetCookies implements the http.CookieJar interface
+func (j *SessionCookieJar) SetCookies(u *url.URL, cs []*http.Cookie) {
+	// Only http and https are supported
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return
+	}
+
+	// Create context
+	ctx, cancel := context.WithTimeout(context.Background(), sessionCookieJarTimeout)
+	defer cancel()
+
+	// Loop through cookies
+	var scs []SessionCookie
+	for _, c := range cs {
+		// Cookie must be removed
+		if c.MaxAge < 0 || (!c.Expires.IsZero() && c.Expires.Before(time.Now())) {
+			if err := j.s.removeCookie(ctx, u.String(), c.Name); err != nil {
+				j.s.l.Error(fmt.Errorf("removing cookie %s of %s failed: %w", c.Name, u, err))
+			}
+			continue
+		}
+		scs = append(scs, httpCookieToSession(u, c))
+	}
+
+	// Set cookies
+	if len(scs) == 0 {
+		return
+	}
+	if err := j.s.setCookies(ctx, scs); err != nil {
+		j.s.l.Error(fmt.Errorf("setting cookies of %s failed: %w", u, err))
+	}
+}
+
+// sessionCookieToHTTP converts a session cookie into an http cookie
+func sessionCookieToHTTP(sc SessionCookie) (c *http.Cookie) {
+	c = &http.Cookie{
+		Domain: sc.Domain,
+		Name:   sc.Name,
+		Path:   sc.Path,
+		Value:  sc.Value,
+	}
+	if sc.HttpOnly != nil {
+		c.HttpOnly = *sc.HttpOnly
+	}
+	if sc.Secure != nil {
+		c.Secure = *sc.Secure
+	}
+	if sc.ExpirationDate != nil {
+		s := int64(*sc.ExpirationDate)
+		c.Expires = time.Unix(s, int64((*sc.ExpirationDate-float64(s))*1e9))
+	}
+	switch sc.SameSite {
+	case SessionCookieSameSiteLax:
+		c.SameSite = http.SameSiteLaxMode
+	case SessionCookieSameSiteNoRestriction:
+		c.SameSite = http.SameSiteNoneMode
+	case SessionCookieSameSiteStrict:
+		c.SameSite = http.SameSiteStrictMode
+	}
+	return
+}
+
+// httpCookieToSession converts an http cookie received from a url into a session cookie
+func httpCookieToSession(u *url.URL, c *http.Cookie) (sc SessionCookie) {
+	sc = SessionCookie{
+		Domain: c.Domain,
+		Name:   c.Name,
+		Path:   c.Path,
+		Url:    u.String(),
+		Value:  c.Value,
+	}
+	if sc.Path == "" {
+		sc.Path = defaultCookiePath(u)
+	}
+	if c.HttpOnly {
+		sc.HttpOnly = astikit.BoolPtr(true)
+	}
+	if c.Secure {
+		sc.Secure = astikit.BoolPtr(true)
+	}
+	if c.MaxAge > 0 {
+		sc.ExpirationDate = sessionCookieExpirationDate(time.Now().Add(time.Duration(c.MaxAge) * time.Second))
+	} else if !c.Expires.IsZero() {
+		sc.ExpirationDate = sessionCookieExpirationDate(c.Expires)
+	}
+	switch c.SameSite {
+	case http.SameSiteLaxMode:
+		sc.SameSite = SessionCookieSameSiteLax
+	case http.SameSiteNoneMode:
+		sc.SameSite = SessionCookieSameSiteNoRestriction
+	case http.SameSiteStrictMode:
+		sc.SameSite = SessionCookieSameSiteStrict
+	}
+	return
+}
+
+// sessionCookieExpirationDate returns the expiration date of a session cookie, which is expressed in seconds since
+// the UNIX epoch
+func sessionCookieExpirationDate(t time.Time) *float64 {
+	d := float64(t.UnixNano()) / 1e9
+	return &d
+}
+
+// defaultCookiePath returns the default path of a cookie received from a url
+// https://tools.ietf.org/html/rfc6265#section-5.1.4
+func defaultCookiePath(u *url.URL) string {
+	p := u.EscapedPath()
+	if p == "" || p[0] != '/' {
+		return sessionCookieJarDefaultPath
+	}
+	if d := path.Dir(p); d != "." {
+		return d
+	}
+	return sessionCookieJarDefaultPath
+}
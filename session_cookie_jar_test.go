@@ -0,0 +1,52 @@
+package astilectron
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astikit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionCookieJar(t *testing.T) {
+	// Init
+	d := newDispatcher()
+	i := newIdentifier()
+	wrt := &mockedWriter{}
+	w := newWriter(wrt, &logger{})
+	s := newSession(context.Background(), &logger{}, d, i, w)
+	var j http.CookieJar = s.CookieJar()
+	u, _ := url.Parse("https://example.com/api/users")
+
+	// Cookies
+	wrt.fn = func() {
+		d.dispatch(Event{CallbackID: "2", Cookies: []SessionCookie{{Domain: "example.com", ExpirationDate: astikit.Float64Ptr(1.5e9), HttpOnly: astikit.BoolPtr(true), Name: "sso", Path: "/", SameSite: SessionCookieSameSiteStrict, Secure: astikit.BoolPtr(true), Value: "token"}}, Name: EventNameSessionEventGetCookies, TargetID: s.id})
+	}
+	assert.Equal(t, []*http.Cookie{{Domain: "example.com", Expires: time.Unix(1.5e9, 0), HttpOnly: true, Name: "sso", Path: "/", SameSite: http.SameSiteStrictMode, Secure: true, Value: "token"}}, j.Cookies(u))
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameSessionCmdGetCookies + "\",\"targetID\":\"1\",\"callbackId\":\"2\",\"cookieFilter\":{\"url\":\"https://example.com/api/users\"}}\n"}, wrt.w)
+
+	// Unsupported scheme
+	wrt.w = []string{}
+	assert.Empty(t, j.Cookies(&url.URL{Scheme: "file", Path: "/tmp"}))
+	assert.Empty(t, wrt.w)
+
+	// Set cookies
+	wrt.fn = func() { d.dispatch(Event{CallbackID: "3", Name: EventNameSessionEventSetCookies, TargetID: s.id}) }
+	j.SetCookies(u, []*http.Cookie{{Expires: time.Unix(4e9, 0), HttpOnly: true, Name: "sso", SameSite: http.SameSiteLaxMode, Value: "token"}})
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameSessionCmdSetCookies + "\",\"targetID\":\"1\",\"callbackId\":\"3\",\"cookies\":[{\"url\":\"https://example.com/api/users\",\"name\":\"sso\",\"value\":\"token\",\"path\":\"/api\",\"httpOnly\":true,\"expirationDate\":4000000000,\"sameSite\":\"lax\"}]}\n"}, wrt.w)
+
+	// Remove cookies
+	wrt.w = []string{}
+	wrt.fn = func() { d.dispatch(Event{CallbackID: "4", Name: EventNameSessionEventCookiesRemoved, TargetID: s.id}) }
+	j.SetCookies(u, []*http.Cookie{{MaxAge: -1, Name: "sso"}})
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameSessionCmdCookiesRemove + "\",\"targetID\":\"1\",\"callbackId\":\"4\",\"cookies\":[{\"url\":\"https://example.com/api/users\",\"name\":\"sso\"}]}\n"}, wrt.w)
+}
+
+func TestDefaultCookiePath(t *testing.T) {
+	for p, e := range map[string]string{"": "/", "/": "/", "/users": "/", "/api/users": "/api", "/api/users/": "/api/users"} {
+		assert.Equal(t, e, defaultCookiePath(&url.URL{Path: p}), p)
+	}
+}
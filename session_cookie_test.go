@@ -0,0 +1,59 @@
+package astilectron
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/asticode/go-astikit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession_Cookies(t *testing.T) {
+	// Init
+	d := newDispatcher()
+	i := newIdentifier()
+	wrt := &mockedWriter{}
+	w := newWriter(wrt, &logger{})
+	s := newSession(context.Background(), &logger{}, d, i, w)
+
+	// Cookies
+	var cs []SessionCookie
+	testObjectRequest(t, func() (err error) {
+		cs, err = s.Cookies(context.Background(), SessionCookieFilter{Domain: "example.com", Secure: astikit.BoolPtr(true)})
+		return
+	}, s.object, wrt, "{\"name\":\""+EventNameSessionCmdGetCookies+"\",\"targetID\":\"1\",\"callbackId\":\"2\",\"cookieFilter\":{\"domain\":\"example.com\",\"secure\":true}}\n", EventNameSessionEventGetCookies, Event{Cookies: []SessionCookie{{Name: "session", Url: "https://example.com"}}})
+	assert.Equal(t, []SessionCookie{{Name: "session", Url: "https://example.com"}}, cs)
+
+	// Cancelled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := s.Cookies(ctx, SessionCookieFilter{})
+	assert.EqualError(t, err, context.Canceled.Error())
+
+	// Remove and flush
+	testObjectRequest(t, func() error { return s.RemoveCookie("https://example.com", "session") }, s.object, wrt, "{\"name\":\""+EventNameSessionCmdCookiesRemove+"\",\"targetID\":\"1\",\"callbackId\":\"3\",\"cookies\":[{\"url\":\"https://example.com\",\"name\":\"session\"}]}\n", EventNameSessionEventCookiesRemoved, Event{})
+	testObjectRequest(t, func() error { return s.FlushCookies() }, s.object, wrt, "{\"name\":\""+EventNameSessionCmdCookiesFlush+"\",\"targetID\":\"1\",\"callbackId\":\"4\"}\n", EventNameSessionEventCookiesFlushed, Event{})
+
+	// Error reported by Electron
+	wrt.fn = func() {
+		d.dispatch(Event{CallbackID: "5", Error: "invalid url", Name: EventNameSessionEventCookiesRemoved, TargetID: s.id})
+	}
+	assert.EqualError(t, s.RemoveCookie("invalid", "session"), "invalid url")
+	wrt.fn = nil
+
+	// Changes
+	wrt.w = []string{}
+	var c SessionCookieChange
+	var wg sync.WaitGroup
+	assert.NoError(t, s.OnCookieChanged(func(i SessionCookieChange) {
+		c = i
+		wg.Done()
+	}))
+	assert.NoError(t, s.OnCookieChanged(func(SessionCookieChange) {}))
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameSessionCmdCookiesOnChanged + "\",\"targetID\":\"1\"}\n"}, wrt.w)
+	wg.Add(1)
+	d.dispatch(Event{CookieChange: &SessionCookieChange{Cause: SessionCookieChangeCauseExplicit, Cookie: SessionCookie{Name: "session"}, Removed: true}, Name: EventNameSessionEventCookiesChanged, TargetID: s.id})
+	wg.Wait()
+	assert.Equal(t, SessionCookieChange{Cause: SessionCookieChangeCauseExplicit, Cookie: SessionCookie{Name: "session"}, Removed: true}, c)
+}
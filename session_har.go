@@ -0,0 +1,654 @@
+package astilectron
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HAR misc
+const (
+	harCreatorName = "go-astilectron"
+	harPriority    = math.MinInt32 // Recording handlers are executed last so that they see the final headers
+	harVersion     = "1.2"
+)
+
+// HARRecordingOptions represents HAR recording options
+// Electron's web request API doesn't expose response bodies: when Bodies is true, response bodies are fetched with the
+// CDP "Network" domain of Debuggers, which must be attached. Their "Network" domain is enabled when the recording starts
+// and disabled when it stops. Responses are matched with the recorded requests by url, in the order the requests
+// started. Request bodies are recorded whether Debuggers is set or not.
+type HARRecordingOptions struct {
+	Bodies    bool
+	Debuggers []*Debugger // Debuggers of the web contents whose response bodies are recorded
+	Patterns  []string    // Chrome match patterns of the requests to record. No pattern means all requests are recorded.
+}
+
+// HAR represents an HTTP Archive
+// http://www.softwareishard.com/blog/har-12-spec/
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog represents a HAR log
+type HARLog struct {
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+	Version string     `json:"version"`
+}
+
+// HARCreator represents the creator of a HAR log
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry represents a HAR entry
+// Error and ResourceType are custom fields, which is why their names start with an underscore
+type HAREntry struct {
+	Cache           struct{}    `json:"cache"`
+	Error           string      `json:"_error,omitempty"`
+	Request         HARRequest  `json:"request"`
+	ResourceType    string      `json:"_resourceType,omitempty"`
+	Response        HARResponse `json:"response"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Timings         HARTimings  `json:"timings"`
+}
+
+// HARRequest represents a HAR request
+type HARRequest struct {
+	BodySize    int            `json:"bodySize"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	HeadersSize int            `json:"headersSize"`
+	HTTPVersion string         `json:"httpVersion"`
+	Method      string         `json:"method"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	QueryString []HARNameValue `json:"queryString"`
+	URL         string         `json:"url"`
+}
+
+// HARResponse represents a HAR response
+type HARResponse struct {
+	BodySize    int            `json:"bodySize"`
+	Content     HARContent     `json:"content"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	HeadersSize int            `json:"headersSize"`
+	HTTPVersion string         `json:"httpVersion"`
+	RedirectURL string         `json:"redirectURL"`
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+}
+
+// HARCookie represents a HAR cookie
+type HARCookie struct {
+	Domain   string     `json:"domain,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	HTTPOnly bool       `json:"httpOnly,omitempty"`
+	Name     string     `json:"name"`
+	Path     string     `json:"path,omitempty"`
+	Secure   bool       `json:"secure,omitempty"`
+	Value    string     `json:"value"`
+}
+
+// HARNameValue represents a HAR header or query string parameter
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData represents the body of a HAR request
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARContent represents the content of a HAR response
+type HARContent struct {
+	Encoding string `json:"encoding,omitempty"`
+	MimeType string `json:"mimeType"`
+	Size     int    `json:"size"`
+	Text     string `json:"text,omitempty"`
+}
+
+// HARTimings represents the timings of a HAR entry, in milliseconds
+// Blocked, Connect, DNS and SSL are set to -1 when they are not available, and Receive, Send and Wait to 0
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	Connect float64 `json:"connect"`
+	DNS     float64 `json:"dns"`
+	Receive float64 `json:"receive"`
+	Send    float64 `json:"send"`
+	SSL     float64 `json:"ssl"`
+	Wait    float64 `json:"wait"`
+}
+
+// HARRecording represents a HAR recording of a session's network traffic
+type HARRecording struct {
+	entries map[int]*harEntry // Indexed by web request id
+	ids     []int             // Web request handler ids
+	m       sync.Mutex
+	o       HARRecordingOptions
+	s       *Session
+	stopped bool
+	w       io.Writer
+}
+
+// harEntry represents a HAR entry being recorded
+type harEntry struct {
+	body            *harBody // Response body, if it has been fetched
+	cdp             bool     // Whether the entry has been matched with a CDP request
+	completed       float64  // Timestamps are in milliseconds since the UNIX epoch
+	d               WebRequestDetails
+	headersReceived float64
+	sendHeaders     float64
+	started         float64
+}
+
+// harBody represents a response body fetched with CDP
+type harBody struct {
+	base64 bool
+	text   string
+}
+
+// StartHARRecording starts recording the session's network traffic
+// The HAR log is written to w once the recording is stopped.
+func (s *Session) StartHARRecording(w io.Writer, o HARRecordingOptions) (r *HARRecording, err error) {
+	// Create recording
+	r = &HARRecording{
+		entries: make(map[int]*harEntry),
+		o:       o,
+		s:       s,
+		w:       w,
+	}
+
+	// Add handlers
+	ho := WebRequestHandlerOptions{Patterns: o.Patterns, Priority: harPriority}
+	for _, fn := range []func() (int, error){
+		func() (int, error) {
+			return s.webRequest.OnBeforeRequest(ho, func(d WebRequestDetails) WebRequestBeforeRequestResponse {
+				r.update(d, func(e *harEntry) { e.started = d.Timestamp })
+				return WebRequestBeforeRequestResponse{}
+			})
+		},
+		func() (int, error) {
+			return s.webRequest.OnBeforeSendHeaders(ho, func(d WebRequestDetails) WebRequestBeforeSendHeadersResponse {
+				r.update(d, func(e *harEntry) { e.sendHeaders = d.Timestamp })
+				return WebRequestBeforeSendHeadersResponse{}
+			})
+		},
+		func() (int, error) {
+			return s.webRequest.OnHeadersReceived(ho, func(d WebRequestDetails) WebRequestHeadersReceivedResponse {
+				r.update(d, func(e *harEntry) { e.headersReceived = d.Timestamp })
+				return WebRequestHeadersReceivedResponse{}
+			})
+		},
+		func() (int, error) {
+			return s.webRequest.OnCompleted(ho, func(d WebRequestDetails) {
+				r.update(d, func(e *harEntry) { e.completed = d.Timestamp })
+			})
+		},
+		func() (int, error) {
+			return s.webRequest.OnErrorOccurred(ho, func(d WebRequestDetails) {
+				r.update(d, func(e *harEntry) { e.completed = d.Timestamp })
+			})
+		},
+	} {
+		var id int
+		if id, err = fn(); err != nil {
+			err = fmt.Errorf("adding web request handler failed: %w", err)
+			r.removeHandlers()
+			return
+		}
+		r.ids = append(r.ids, id)
+	}
+
+	// Record response bodies
+	if o.Bodies {
+		for _, d := range o.Debuggers {
+			if err = r.recordBodies(d); err != nil {
+				err = fmt.Errorf("recording response bodies failed: %w", err)
+				r.removeHandlers()
+				return
+			}
+		}
+	}
+	return
+}
+
+// recordBodies enables the debugger's "Network" domain and fetches the body of every response once it has loaded
+func (r *HARRecording) recordBodies(d *Debugger) (err error) {
+	// Listen
+	ids := make(map[string]int) // Web request ids indexed by CDP request id
+	var m sync.Mutex
+	d.OnEvent("Network.responseReceived", func(e DebuggerEvent) (deleteListener bool) {
+		var p struct {
+			RequestID string `json:"requestId"`
+			Response  struct {
+				URL string `json:"url"`
+			} `json:"response"`
+		}
+		if err := json.Unmarshal(e.Params, &p); err != nil {
+			r.s.l.Error(fmt.Errorf("unmarshaling %s params failed: %w", e.Method, err))
+			return r.isStopped()
+		}
+		if id, ok := r.matchCDPRequest(p.Response.URL); ok {
+			m.Lock()
+			ids[p.RequestID] = id
+			m.Unlock()
+		}
+		return r.isStopped()
+	})
+	d.OnEvent("Network.loadingFinished", func(e DebuggerEvent) (deleteListener bool) {
+		var p struct {
+			RequestID string `json:"requestId"`
+		}
+		if err := json.Unmarshal(e.Params, &p); err != nil {
+			r.s.l.Error(fmt.Errorf("unmarshaling %s params failed: %w", e.Method, err))
+			return r.isStopped()
+		}
+		m.Lock()
+		id, ok := ids[p.RequestID]
+		delete(ids, p.RequestID)
+		m.Unlock()
+		if ok && !r.isStopped() {
+			// Fetching the body must not block the delivery of CDP events
+			go r.fetchBody(d, p.RequestID, id)
+		}
+		return r.isStopped()
+	})
+
+	// Enable network domain
+	if err = d.SendCommand(context.Background(), "Network.enable", nil, nil); err != nil {
+		err = fmt.Errorf("enabling network domain failed: %w", err)
+		return
+	}
+	return
+}
+
+// matchCDPRequest returns the id of the web request a CDP request corresponds to, which is the first started web
+// request to the url that hasn't been matched yet
+func (r *HARRecording) matchCDPRequest(url string) (id int, ok bool) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	var m *harEntry
+	for _, e := range r.entries {
+		if e.cdp || e.d.URL != url {
+			continue
+		}
+		if m == nil || e.started < m.started || (e.started == m.started && e.d.ID < m.d.ID) {
+			m = e
+		}
+	}
+	if m == nil {
+		return
+	}
+	m.cdp = true
+	return m.d.ID, true
+}
+
+// fetchBody fetches the body of a response and stores it in the entry of its web request
+func (r *HARRecording) fetchBody(d *Debugger, requestID string, id int) {
+	// Fetch
+	var b struct {
+		Base64Encoded bool   `json:"base64Encoded"`
+		Body          string `json:"body"`
+	}
+	if err := d.SendCommand(context.Background(), "Network.getResponseBody", map[string]string{"requestId": requestID}, &b); err != nil {
+		r.s.l.Error(fmt.Errorf("getting response body of web request %d failed: %w", id, err))
+		return
+	}
+
+	// Store
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.stopped {
+		return
+	}
+	if e, ok := r.entries[id]; ok {
+		e.body = &harBody{base64: b.Base64Encoded, text: b.Body}
+	}
+}
+
+// isStopped returns whether the recording is stopped
+func (r *HARRecording) isStopped() bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.stopped
+}
+
+// update merges the details of a web request lifecycle event into its entry
+func (r *HARRecording) update(d WebRequestDetails, fn func(e *harEntry)) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.stopped {
+		return
+	}
+	e, ok := r.entries[d.ID]
+	if !ok {
+		e = &harEntry{}
+		r.entries[d.ID] = e
+	}
+	e.d = mergeWebRequestDetails(e.d, d)
+	fn(e)
+}
+
+// mergeWebRequestDetails merges the details of a lifecycle event into the details known so far
+func mergeWebRequestDetails(o, n WebRequestDetails) WebRequestDetails {
+	o.ID = n.ID
+	if n.Error != "" {
+		o.Error = n.Error
+	}
+	o.FromCache = o.FromCache || n.FromCache
+	if n.IP != "" {
+		o.IP = n.IP
+	}
+	if n.Method != "" {
+		o.Method = n.Method
+	}
+	if n.Referrer != "" {
+		o.Referrer = n.Referrer
+	}
+	if n.RequestHeaders != nil {
+		o.RequestHeaders = n.RequestHeaders
+	}
+	if n.ResourceType != "" {
+		o.ResourceType = n.ResourceType
+	}
+	if n.ResponseHeaders != nil {
+		o.ResponseHeaders = n.ResponseHeaders
+	}
+	if n.StatusCode != 0 {
+		o.StatusCode = n.StatusCode
+	}
+	if n.StatusLine != "" {
+		o.StatusLine = n.StatusLine
+	}
+	if n.UploadData != nil {
+		o.UploadData = n.UploadData
+	}
+	if n.URL != "" {
+		o.URL = n.URL
+	}
+	return o
+}
+
+// Stop stops the recording and writes the HAR log
+// Requests that are still in flight are written as well, without response.
+func (r *HARRecording) Stop() (err error) {
+	// Stop
+	r.m.Lock()
+	if r.stopped {
+		r.m.Unlock()
+		return errors.New("HAR recording is already stopped")
+	}
+	r.stopped = true
+	r.m.Unlock()
+
+	// Remove handlers
+	r.removeHandlers()
+
+	// Disable network domains
+	if r.o.Bodies {
+		for _, d := range r.o.Debuggers {
+			if err := d.SendCommand(context.Background(), "Network.disable", nil, nil); err != nil {
+				r.s.l.Error(fmt.Errorf("disabling network domain failed: %w", err))
+			}
+		}
+	}
+
+	// Write
+	if err = json.NewEncoder(r.w).Encode(r.HAR()); err != nil {
+		err = fmt.Errorf("writing HAR log failed: %w", err)
+		return
+	}
+	return
+}
+
+// removeHandlers removes the recording's web request handlers
+func (r *HARRecording) removeHandlers() {
+	for _, id := range r.ids {
+		if err := r.s.webRequest.Remove(id); err != nil {
+			r.s.l.Error(fmt.Errorf("removing web request handler %d failed: %w", id, err))
+		}
+	}
+	r.ids = nil
+}
+
+// HAR returns the HAR log recorded so far
+func (r *HARRecording) HAR() (h HAR) {
+	// Create log
+	h.Log = HARLog{
+		Creator: HARCreator{Name: harCreatorName, Version: DefaultVersionAstilectron},
+		Entries: []HAREntry{},
+		Version: harVersion,
+	}
+
+	// Add entries
+	r.m.Lock()
+	for _, e := range r.entries {
+		h.Log.Entries = append(h.Log.Entries, e.har(r.o.Bodies))
+	}
+	r.m.Unlock()
+
+	// Sort entries
+	sort.SliceStable(h.Log.Entries, func(i, j int) bool {
+		return h.Log.Entries[i].StartedDateTime.Before(h.Log.Entries[j].StartedDateTime)
+	})
+	return
+}
+
+// har converts the entry into a HAR entry
+func (e *harEntry) har(bodies bool) (h HAREntry) {
+	// Create entry
+	started := e.started
+	if started == 0 {
+		started = firstNonZero(e.sendHeaders, e.headersReceived, e.completed)
+	}
+	h = HAREntry{
+		Error:           e.d.Error,
+		ResourceType:    e.d.ResourceType,
+		ServerIPAddress: e.d.IP,
+		StartedDateTime: harTime(started),
+		Timings: HARTimings{
+			Blocked: harOptionalDuration(started, e.sendHeaders),
+			Connect: -1,
+			DNS:     -1,
+			Receive: harDuration(e.headersReceived, e.completed),
+			SSL:     -1,
+			Wait:    harDuration(firstNonZero(e.sendHeaders, started), e.headersReceived),
+		},
+	}
+	for _, t := range []float64{h.Timings.Blocked, h.Timings.Receive, h.Timings.Wait} {
+		if t > 0 {
+			h.Time += t
+		}
+	}
+
+	// Request
+	httpVersion := harHTTPVersion(e.d.StatusLine)
+	rh := make(http.Header)
+	for k, v := range e.d.RequestHeaders {
+		rh.Set(k, v)
+	}
+	h.Request = HARRequest{
+		BodySize:    0,
+		Cookies:     harCookies((&http.Request{Header: rh}).Cookies()),
+		Headers:     harRequestHeaders(e.d.RequestHeaders),
+		HeadersSize: -1,
+		HTTPVersion: httpVersion,
+		Method:      e.d.Method,
+		QueryString: []HARNameValue{},
+		URL:         e.d.URL,
+	}
+	if u, err := url.Parse(e.d.URL); err == nil {
+		for k, vs := range u.Query() {
+			for _, v := range vs {
+				h.Request.QueryString = append(h.Request.QueryString, HARNameValue{Name: k, Value: v})
+			}
+		}
+		sort.SliceStable(h.Request.QueryString, func(i, j int) bool { return h.Request.QueryString[i].Name < h.Request.QueryString[j].Name })
+	}
+	if len(e.d.UploadData) > 0 {
+		buf := &bytes.Buffer{}
+		for _, d := range e.d.UploadData {
+			buf.Write(d.Bytes)
+		}
+		h.Request.BodySize = buf.Len()
+		if bodies {
+			h.Request.PostData = &HARPostData{MimeType: rh.Get("Content-Type"), Text: buf.String()}
+		}
+	}
+
+	// Response
+	hh := http.Header(e.d.ResponseHeaders)
+	h.Response = HARResponse{
+		BodySize:    -1,
+		Content:     HARContent{MimeType: harHeader(hh, "Content-Type"), Size: -1},
+		Cookies:     harCookies((&http.Response{Header: canonicalHeader(hh)}).Cookies()),
+		Headers:     harResponseHeaders(e.d.ResponseHeaders),
+		HeadersSize: -1,
+		HTTPVersion: httpVersion,
+		RedirectURL: harHeader(hh, "Location"),
+		Status:      e.d.StatusCode,
+		StatusText:  harStatusText(e.d.StatusLine, e.d.StatusCode),
+	}
+	if e.d.FromCache {
+		h.Response.BodySize = 0
+	} else if l, err := strconv.Atoi(harHeader(hh, "Content-Length")); err == nil {
+		h.Response.BodySize = l
+		h.Response.Content.Size = l
+	}
+	if bodies && e.body != nil {
+		h.Response.Content.Text = e.body.text
+		if e.body.base64 {
+			h.Response.Content.Encoding = "base64"
+		}
+	}
+	return
+}
+
+// harTime converts a timestamp in milliseconds since the UNIX epoch into a time
+func harTime(ms float64) time.Time {
+	return time.Unix(0, int64(ms*1e6)).UTC()
+}
+
+// harDuration returns the duration between 2 timestamps, or 0 if one of them is unknown
+func harDuration(from, to float64) float64 {
+	if d := harOptionalDuration(from, to); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// harOptionalDuration returns the duration between 2 timestamps, or -1 if one of them is unknown
+func harOptionalDuration(from, to float64) float64 {
+	if from == 0 || to == 0 || to < from {
+		return -1
+	}
+	return to - from
+}
+
+// firstNonZero returns the first non-zero value
+func firstNonZero(vs ...float64) float64 {
+	for _, v := range vs {
+		if v != 0 {
+			return v
+		}
+	}
+	return 0
+}
+
+// harHTTPVersion returns the http version of a status line such as "HTTP/1.1 200 OK"
+func harHTTPVersion(statusLine string) string {
+	if strings.HasPrefix(statusLine, "HTTP/") {
+		return strings.SplitN(statusLine, " ", 2)[0]
+	}
+	return ""
+}
+
+// harStatusText returns the status text of a status line such as "HTTP/1.1 200 OK"
+func harStatusText(statusLine string, code int) string {
+	if ps := strings.SplitN(statusLine, " ", 3); len(ps) == 3 {
+		return ps[2]
+	}
+	return http.StatusText(code)
+}
+
+// harHeader returns the value of a response header whose name is case insensitive
+func harHeader(h http.Header, name string) string {
+	for k, vs := range h {
+		if strings.EqualFold(k, name) && len(vs) > 0 {
+			return vs[0]
+		}
+	}
+	return ""
+}
+
+// canonicalHeader returns a copy of response headers whose names are canonicalized
+func canonicalHeader(h http.Header) (o http.Header) {
+	o = make(http.Header, len(h))
+	for k, vs := range h {
+		for _, v := range vs {
+			o.Add(k, v)
+		}
+	}
+	return
+}
+
+// harRequestHeaders converts request headers into sorted HAR headers
+func harRequestHeaders(h map[string]string) (o []HARNameValue) {
+	o = []HARNameValue{}
+	for k, v := range h {
+		o = append(o, HARNameValue{Name: k, Value: v})
+	}
+	sort.SliceStable(o, func(i, j int) bool { return o[i].Name < o[j].Name })
+	return
+}
+
+// harResponseHeaders converts response headers into sorted HAR headers
+func harResponseHeaders(h map[string][]string) (o []HARNameValue) {
+	o = []HARNameValue{}
+	for k, vs := range h {
+		for _, v := range vs {
+			o = append(o, HARNameValue{Name: k, Value: v})
+		}
+	}
+	sort.SliceStable(o, func(i, j int) bool { return o[i].Name < o[j].Name })
+	return
+}
+
+// harCookies converts http cookies into HAR cookies
+func harCookies(cs []*http.Cookie) (o []HARCookie) {
+	o = []HARCookie{}
+	for _, c := range cs {
+		hc := HARCookie{
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Name:     c.Name,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			Value:    c.Value,
+		}
+		if !c.Expires.IsZero() {
+			t := c.Expires.UTC()
+			hc.Expires = &t
+		}
+		o = append(o, hc)
+	}
+	return
+}
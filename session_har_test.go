@@ -0,0 +1,178 @@
+package astilectron
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession_HARRecording(t *testing.T) {
+	// Init
+	d := newDispatcher()
+	i := newIdentifier()
+	wrt := &mockedWriter{wg: &sync.WaitGroup{}}
+	w := newWriter(wrt, &logger{})
+	s := newSession(context.Background(), &logger{}, d, i, w)
+
+	// Start
+	buf := &bytes.Buffer{}
+	wrt.wg.Add(5)
+	r, err := s.StartHARRecording(buf, HARRecordingOptions{Bodies: true})
+	assert.NoError(t, err)
+	wrt.wg.Wait()
+
+	// Successful request
+	for _, e := range []Event{
+		{CallbackID: "1", Name: EventNameSessionEventWebRequestOnBeforeRequest, WebRequest: &WebRequestDetails{ID: 1, Method: "POST", ResourceType: WebRequestResourceTypeXHR, Timestamp: 1000, UploadData: []WebRequestUploadData{{Bytes: []byte("a=b")}}, URL: "https://example.com/path?b=2&a=1"}},
+		{CallbackID: "2", Name: EventNameSessionEventWebRequestOnBeforeSendHeaders, WebRequest: &WebRequestDetails{ID: 1, RequestHeaders: map[string]string{"Content-Type": "application/x-www-form-urlencoded", "Cookie": "k=v"}, Timestamp: 1010}},
+		{CallbackID: "3", Name: EventNameSessionEventWebRequestOnHeadersReceived, WebRequest: &WebRequestDetails{ID: 1, ResponseHeaders: map[string][]string{"content-length": {"5"}, "content-type": {"text/plain"}, "set-cookie": {"n=m; Path=/; HttpOnly"}}, StatusCode: 201, StatusLine: "HTTP/1.1 201 Created", Timestamp: 1050}},
+	} {
+		wrt.wg.Add(1)
+		e.TargetID = s.id
+		d.dispatch(e)
+		wrt.wg.Wait()
+	}
+	d.dispatch(Event{Name: EventNameSessionEventWebRequestOnCompleted, TargetID: s.id, WebRequest: &WebRequestDetails{ID: 1, IP: "1.2.3.4", StatusCode: 201, Timestamp: 1060}})
+	testEventually(t, func() bool { return r.HAR().Log.Entries[0].ServerIPAddress != "" })
+
+	// Failed request
+	d.dispatch(Event{Name: EventNameSessionEventWebRequestOnErrorOccurred, TargetID: s.id, WebRequest: &WebRequestDetails{Error: "net::ERR_NAME_NOT_RESOLVED", ID: 2, Method: "GET", Timestamp: 2000, URL: "https://unknown.example.com/"}})
+	testEventually(t, func() bool { return len(r.HAR().Log.Entries) == 2 })
+
+	// Stop
+	wrt.w = []string{}
+	wrt.wg.Add(5)
+	assert.NoError(t, r.Stop())
+	wrt.wg.Wait()
+	assert.Equal(t, []string{
+		"{\"name\":\"" + EventNameSessionCmdWebRequestOffBeforeRequest + "\",\"targetID\":\"" + s.id + "\"}\n",
+		"{\"name\":\"" + EventNameSessionCmdWebRequestOffBeforeSendHeaders + "\",\"targetID\":\"" + s.id + "\"}\n",
+		"{\"name\":\"" + EventNameSessionCmdWebRequestOffHeadersReceived + "\",\"targetID\":\"" + s.id + "\"}\n",
+		"{\"name\":\"" + EventNameSessionCmdWebRequestOffCompleted + "\",\"targetID\":\"" + s.id + "\"}\n",
+		"{\"name\":\"" + EventNameSessionCmdWebRequestOffErrorOccurred + "\",\"targetID\":\"" + s.id + "\"}\n",
+	}, wrt.w)
+	assert.Error(t, r.Stop())
+	var h HAR
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &h))
+	assert.Equal(t, HARLog{
+		Creator: HARCreator{Name: "go-astilectron", Version: DefaultVersionAstilectron},
+		Entries: []HAREntry{
+			{
+				Request: HARRequest{
+					BodySize:    3,
+					Cookies:     []HARCookie{{Name: "k", Value: "v"}},
+					Headers:     []HARNameValue{{Name: "Content-Type", Value: "application/x-www-form-urlencoded"}, {Name: "Cookie", Value: "k=v"}},
+					HeadersSize: -1,
+					HTTPVersion: "HTTP/1.1",
+					Method:      "POST",
+					PostData:    &HARPostData{MimeType: "application/x-www-form-urlencoded", Text: "a=b"},
+					QueryString: []HARNameValue{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}},
+					URL:         "https://example.com/path?b=2&a=1",
+				},
+				ResourceType: WebRequestResourceTypeXHR,
+				Response: HARResponse{
+					BodySize:    5,
+					Content:     HARContent{MimeType: "text/plain", Size: 5},
+					Cookies:     []HARCookie{{HTTPOnly: true, Name: "n", Path: "/", Value: "m"}},
+					Headers:     []HARNameValue{{Name: "content-length", Value: "5"}, {Name: "content-type", Value: "text/plain"}, {Name: "set-cookie", Value: "n=m; Path=/; HttpOnly"}},
+					HeadersSize: -1,
+					HTTPVersion: "HTTP/1.1",
+					Status:      201,
+					StatusText:  "Created",
+				},
+				ServerIPAddress: "1.2.3.4",
+				StartedDateTime: time.Unix(1, 0).UTC(),
+				Time:            60,
+				Timings:         HARTimings{Blocked: 10, Connect: -1, DNS: -1, Receive: 10, SSL: -1, Wait: 40},
+			},
+			{
+				Error: "net::ERR_NAME_NOT_RESOLVED",
+				Request: HARRequest{
+					Cookies:     []HARCookie{},
+					Headers:     []HARNameValue{},
+					HeadersSize: -1,
+					Method:      "GET",
+					QueryString: []HARNameValue{},
+					URL:         "https://unknown.example.com/",
+				},
+				Response: HARResponse{
+					BodySize:    -1,
+					Content:     HARContent{Size: -1},
+					Cookies:     []HARCookie{},
+					Headers:     []HARNameValue{},
+					HeadersSize: -1,
+				},
+				StartedDateTime: time.Unix(2, 0).UTC(),
+				Timings:         HARTimings{Blocked: -1, Connect: -1, DNS: -1, SSL: -1},
+			},
+		},
+		Version: "1.2",
+	}, h.Log)
+}
+
+func TestSession_HARRecordingBodies(t *testing.T) {
+	// Init
+	a, err := New(nil, Options{})
+	assert.NoError(t, err)
+	defer a.Close()
+	wrt := &mockedWriter{}
+	a.writer = newWriter(wrt, &logger{})
+	w, err := a.NewWindow("http://test.com", &WindowOptions{})
+	assert.NoError(t, err)
+	d := w.Debugger()
+	var methods []string
+	wrt.fn = func() {
+		var e Event
+		json.Unmarshal([]byte(wrt.w[len(wrt.w)-1]), &e)
+		if e.Name != EventNameWebContentsCmdDebuggerSendCommand {
+			return
+		}
+		methods = append(methods, e.Debugger.Method)
+		r := Event{CallbackID: e.CallbackID, Debugger: &EventDebugger{}, Name: EventNameWebContentsEventDebuggerSentCommand, TargetID: w.id}
+		if e.Debugger.Method == "Network.getResponseBody" {
+			var p struct {
+				RequestID string `json:"requestId"`
+			}
+			json.Unmarshal(e.Debugger.Params, &p)
+			r.Debugger.Result = json.RawMessage("{\"base64Encoded\":true,\"body\":\"" + base64.StdEncoding.EncodeToString([]byte(p.RequestID)) + "\"}")
+		}
+		w.d.dispatch(r)
+	}
+
+	// Start
+	r, err := w.Session.StartHARRecording(&bytes.Buffer{}, HARRecordingOptions{Bodies: true, Debuggers: []*Debugger{d}})
+	assert.NoError(t, err)
+
+	// Responses to the same url are matched with their own request
+	for idx := 1; idx <= 2; idx++ {
+		w.d.dispatch(Event{Name: EventNameSessionEventWebRequestOnCompleted, TargetID: w.Session.id, WebRequest: &WebRequestDetails{ID: idx, Method: "GET", StatusCode: 200, Timestamp: float64(1000 * idx), URL: "https://example.com/"}})
+	}
+	testEventually(t, func() bool { return len(r.HAR().Log.Entries) == 2 })
+	for _, id := range []string{"first", "second"} {
+		w.d.dispatch(Event{Debugger: &EventDebugger{Method: "Network.responseReceived", Params: json.RawMessage("{\"requestId\":\"" + id + "\",\"response\":{\"url\":\"https://example.com/\"}}")}, Name: EventNameWebContentsEventDebuggerMessage, TargetID: w.id})
+	}
+	for _, id := range []string{"second", "first"} {
+		w.d.dispatch(Event{Debugger: &EventDebugger{Method: "Network.loadingFinished", Params: json.RawMessage("{\"requestId\":\"" + id + "\"}")}, Name: EventNameWebContentsEventDebuggerMessage, TargetID: w.id})
+	}
+	testEventually(t, func() bool {
+		for _, e := range r.HAR().Log.Entries {
+			if e.Response.Content.Text == "" {
+				return false
+			}
+		}
+		return true
+	})
+	es := r.HAR().Log.Entries
+	assert.Equal(t, HARContent{Encoding: "base64", Size: -1, Text: base64.StdEncoding.EncodeToString([]byte("first"))}, es[0].Response.Content)
+	assert.Equal(t, HARContent{Encoding: "base64", Size: -1, Text: base64.StdEncoding.EncodeToString([]byte("second"))}, es[1].Response.Content)
+
+	// Stop
+	assert.NoError(t, r.Stop())
+	assert.Equal(t, []string{"Network.enable", "Network.getResponseBody", "Network.getResponseBody", "Network.disable"}, methods)
+}
@@ -0,0 +1,282 @@
+package astilectron
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"sync"
+)
+
+// Session mock event names
+const (
+	EventNameSessionCmdInterceptProtocol               = "session.cmd.intercept.protocol"
+	EventNameSessionCmdUninterceptProtocol             = "session.cmd.unintercept.protocol"
+	EventNameSessionEventInterceptedProtocol           = "session.event.intercepted.protocol"
+	EventNameSessionEventInterceptedRequest            = "session.event.intercepted.request"
+	EventNameSessionEventInterceptedRequestPassthrough = "session.event.intercepted.request.passthrough"
+	EventNameSessionEventUninterceptedProtocol         = "session.event.unintercepted.protocol"
+)
+
+// sessionMocks represents the mocks of a session
+// Electron intercepts the schemes of the mocks' patterns and forwards every request of those schemes: requests
+// matching a mock are served in GO, other requests are sent to the network by Electron.
+type sessionMocks struct {
+	id      int // Last mock id
+	m       sync.Mutex
+	ms      []*sessionMock
+	s       *Session
+	schemes map[string]*sessionMockScheme // Indexed by scheme
+}
+
+// sessionMock represents a session mock
+type sessionMock struct {
+	h       http.Handler
+	id      int
+	p       *webRequestPattern
+	schemes []*sessionMockScheme
+	skip    func(r *http.Request) bool // Whether a matching request must be sent to the network anyway
+}
+
+// sessionMockScheme represents a scheme intercepted for mocks
+// done is closed once Electron has answered the intercept request, after which err can be read. count is locked by
+// the mocks' mutex.
+type sessionMockScheme struct {
+	count int // Number of mocks using the scheme
+	done  chan struct{}
+	err   error
+	name  string
+}
+
+// newSessionMocks creates new session mocks
+func newSessionMocks(s *Session) (m *sessionMocks) {
+	m = &sessionMocks{
+		s:       s,
+		schemes: make(map[string]*sessionMockScheme),
+	}
+	s.On(EventNameSessionEventInterceptedRequest, func(e Event) (deleteListener bool) {
+		m.serve(e)
+		return
+	})
+	return
+}
+
+// Mock serves the requests of the session matching a pattern with an http.Handler, including requests to real
+// https:// origins
+// The pattern uses Chrome's match pattern syntax (e.g. "https://api.example.com/*"). Requests that don't match any
+// mock are sent to the network. When several mocks match a request, the one added first wins. Websockets can't be
+// mocked. A panicking handler answers with an internal server error, as with net/http.
+func (s *Session) Mock(pattern string, h http.Handler) (id int, err error) {
+	return s.mocks.add(pattern, h, nil)
+}
+
+// MockDirectory serves the requests of the session matching a pattern with the files of a fixture directory
+// The request's url path is used as the file path inside the directory. Requests for files that don't exist in the
+// directory are sent to the network.
+func (s *Session) MockDirectory(pattern, dir string) (id int, err error) {
+	fs := http.Dir(dir)
+	return s.mocks.add(pattern, http.FileServer(fs), func(r *http.Request) bool {
+		f, err := fs.Open(path.Clean("/" + r.URL.Path))
+		if err != nil {
+			return true
+		}
+		defer f.Close()
+		return false
+	})
+}
+
+// Unmock removes a mock
+// Schemes that are not used by any mock anymore stop being intercepted.
+func (s *Session) Unmock(id int) error {
+	return s.mocks.del(id)
+}
+
+// add adds a mock and makes sure Electron intercepts its schemes
+// If a scheme is being intercepted for another mock, add waits for Electron's answer instead of sending the request
+// once again.
+func (m *sessionMocks) add(pattern string, h http.Handler, skip func(r *http.Request) bool) (id int, err error) {
+	// Check context
+	if err = m.s.ctx.Err(); err != nil {
+		return
+	}
+
+	// Parse pattern
+	mk := &sessionMock{h: h, skip: skip}
+	if mk.p, err = parseWebRequestPattern(pattern); err != nil {
+		err = fmt.Errorf("parsing pattern %s failed: %w", pattern, err)
+		return
+	}
+	if mk.p.scheme == "ws" || mk.p.scheme == "wss" {
+		err = fmt.Errorf("pattern %s can't be mocked since websockets can't be intercepted", pattern)
+		return
+	}
+
+	// Add mock
+	var intercepts []*sessionMockScheme
+	m.m.Lock()
+	m.id++
+	mk.id = m.id
+	id = mk.id
+	m.ms = append(m.ms, mk)
+	for _, name := range mk.p.interceptedSchemes() {
+		sc, ok := m.schemes[name]
+		if !ok {
+			sc = &sessionMockScheme{done: make(chan struct{}), name: name}
+			m.schemes[name] = sc
+			intercepts = append(intercepts, sc)
+		}
+		sc.count++
+		mk.schemes = append(mk.schemes, sc)
+	}
+	m.m.Unlock()
+
+	// Intercept schemes that are not intercepted yet
+	for idx, sc := range intercepts {
+		if _, err = synchronousRequest(context.Background(), m.s.object, Event{Name: EventNameSessionCmdInterceptProtocol, Scheme: sc.name, TargetID: m.s.id}, EventNameSessionEventInterceptedProtocol); err != nil {
+			// Schemes that have not been intercepted yet fail as well so that the next mock tries again
+			m.m.Lock()
+			for _, f := range intercepts[idx:] {
+				f.err = err
+				if m.schemes[f.name] == f {
+					delete(m.schemes, f.name)
+				}
+				close(f.done)
+			}
+			m.m.Unlock()
+			break
+		}
+		close(sc.done)
+	}
+
+	// Wait for schemes being intercepted for other mocks
+	for _, sc := range mk.schemes {
+		<-sc.done
+		if sc.err != nil {
+			err = fmt.Errorf("intercepting scheme %s failed: %w", sc.name, sc.err)
+			break
+		}
+	}
+	if err == nil {
+		return
+	}
+
+	// Remove mock and stop intercepting the schemes that have been intercepted for it
+	m.m.Lock()
+	schemes, _ := m.remove(id)
+	m.m.Unlock()
+	if uerr := m.unintercept(schemes); uerr != nil {
+		m.s.l.Error(fmt.Errorf("unintercepting schemes of mock %d failed: %w", id, uerr))
+	}
+	return
+}
+
+// del removes a mock and stops intercepting the schemes that are not used anymore
+func (m *sessionMocks) del(id int) (err error) {
+	// Check context
+	if err = m.s.ctx.Err(); err != nil {
+		return
+	}
+
+	// Remove mock
+	m.m.Lock()
+	schemes, ok := m.remove(id)
+	m.m.Unlock()
+	if !ok {
+		err = fmt.Errorf("mock %d doesn't exist", id)
+		return
+	}
+
+	// Unintercept schemes
+	return m.unintercept(schemes)
+}
+
+// remove removes a mock and returns the schemes that are not used anymore
+// It assumes the mutex is locked
+func (m *sessionMocks) remove(id int) (schemes []*sessionMockScheme, ok bool) {
+	for idx, mk := range m.ms {
+		if mk.id != id {
+			continue
+		}
+		m.ms = append(m.ms[:idx:idx], m.ms[idx+1:]...)
+		for _, sc := range mk.schemes {
+			if sc.count--; sc.count <= 0 {
+				if m.schemes[sc.name] == sc {
+					delete(m.schemes, sc.name)
+				}
+				schemes = append(schemes, sc)
+			}
+		}
+		return schemes, true
+	}
+	return
+}
+
+// unintercept stops intercepting schemes once Electron has answered their intercept request
+// Schemes whose interception has failed are skipped.
+func (m *sessionMocks) unintercept(schemes []*sessionMockScheme) (err error) {
+	for _, sc := range schemes {
+		<-sc.done
+		if sc.err != nil {
+			continue
+		}
+		if _, err = synchronousRequest(context.Background(), m.s.object, Event{Name: EventNameSessionCmdUninterceptProtocol, Scheme: sc.name, TargetID: m.s.id}, EventNameSessionEventUninterceptedProtocol); err != nil {
+			err = fmt.Errorf("unintercepting scheme %s failed: %w", sc.name, err)
+			return
+		}
+	}
+	return
+}
+
+// serve serves an intercepted request with the first matching mock, or lets Electron send it to the network
+func (m *sessionMocks) serve(e Event) {
+	// Create request
+	r, err := newProtocolRequest(m.s.ctx, e)
+	if err != nil {
+		m.s.l.Error(fmt.Errorf("creating intercepted request failed: %w", err))
+		m.passthrough(e)
+		return
+	}
+
+	// Get mock
+	mk := m.match(r)
+	if mk == nil {
+		m.passthrough(e)
+		return
+	}
+
+	// Serve
+	rw := newProtocolResponseWriter(m.s.l, m.s.w, m.s.id, e.CallbackID)
+	defer rw.close()
+	defer rw.recoverPanic(r)
+	mk.h.ServeHTTP(rw, r)
+}
+
+// match returns the first mock matching a request
+func (m *sessionMocks) match(r *http.Request) *sessionMock {
+	m.m.Lock()
+	ms := append([]*sessionMock{}, m.ms...)
+	m.m.Unlock()
+	for _, mk := range ms {
+		if mk.p.matches(r.URL) && (mk.skip == nil || !mk.skip(r)) {
+			return mk
+		}
+	}
+	return nil
+}
+
+// passthrough lets Electron know it should send an intercepted request to the network
+func (m *sessionMocks) passthrough(e Event) {
+	if err := m.s.w.write(Event{CallbackID: e.CallbackID, Name: EventNameSessionEventInterceptedRequestPassthrough, TargetID: m.s.id}); err != nil {
+		m.s.l.Error(fmt.Errorf("writing %s event failed: %w", EventNameSessionEventInterceptedRequestPassthrough, err))
+	}
+}
+
+// interceptedSchemes returns the schemes that must be intercepted for the pattern to be matched
+// Websockets can't be intercepted, which is why "*" only intercepts http and https and why patterns with a websocket
+// scheme are rejected.
+func (p *webRequestPattern) interceptedSchemes() []string {
+	if p.all || p.scheme == "*" {
+		return []string{"http", "https"}
+	}
+	return []string{p.scheme}
+}
@@ -0,0 +1,176 @@
+package astilectron
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession_Mock(t *testing.T) {
+	// Init
+	d := newDispatcher()
+	i := newIdentifier()
+	wrt := &mockedWriter{}
+	w := newWriter(wrt, &logger{})
+	s := newSession(context.Background(), &logger{}, d, i, w)
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "users"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "users", "1.json"), []byte("{\"id\":1}"), 0644))
+
+	// Invalid pattern
+	_, err := s.Mock("invalid", http.NotFoundHandler())
+	assert.Error(t, err)
+	_, err = s.Mock("wss://api.example.com/*", http.NotFoundHandler())
+	assert.Error(t, err)
+
+	// Mock
+	var id int
+	testObjectRequest(t, func() (err error) {
+		id, err = s.Mock("https://api.example.com/v1/*", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Set("Content-Type", "application/json")
+			rw.Write([]byte("{\"path\":\"" + r.URL.Path + "\"}"))
+		}))
+		return
+	}, s.object, wrt, "{\"name\":\""+EventNameSessionCmdInterceptProtocol+"\",\"targetID\":\"1\",\"callbackId\":\"2\",\"scheme\":\"https\"}\n", EventNameSessionEventInterceptedProtocol, Event{})
+	assert.Equal(t, 1, id)
+
+	// Mocking a pattern whose scheme is already intercepted doesn't intercept it again, whereas "*" intercepts http
+	// as well
+	wrt.w = []string{}
+	wrt.fn = func() {
+		d.dispatch(Event{CallbackID: "3", Name: EventNameSessionEventInterceptedProtocol, TargetID: s.id})
+	}
+	idDir, err := s.MockDirectory("*://fixtures.example.com/*", dir)
+	wrt.fn = nil
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameSessionCmdInterceptProtocol + "\",\"targetID\":\"1\",\"callbackId\":\"3\",\"scheme\":\"http\"}\n"}, wrt.w)
+	idPanic, err := s.Mock("https://panic.example.com/*", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte("partial"))
+		panic("mock")
+	}))
+	assert.NoError(t, err)
+
+	// Serve
+	for _, v := range []struct {
+		expected []string
+		url      string
+	}{
+		{
+			expected: []string{
+				"{\"name\":\"" + EventNameProtocolEventRequestCallback + "\",\"targetID\":\"1\",\"callbackId\":\"7\",\"headers\":{\"Content-Type\":[\"application/json\"]},\"statusCode\":200}\n",
+				"{\"name\":\"" + EventNameProtocolEventRequestCallbackData + "\",\"targetID\":\"1\",\"bytes\":\"eyJwYXRoIjoiL3YxL3VzZXJzIn0=\",\"callbackId\":\"7\"}\n",
+				"{\"name\":\"" + EventNameProtocolEventRequestCallbackEnd + "\",\"targetID\":\"1\",\"callbackId\":\"7\"}\n",
+			},
+			url: "https://api.example.com/v1/users",
+		},
+		{
+			expected: []string{"{\"name\":\"" + EventNameSessionEventInterceptedRequestPassthrough + "\",\"targetID\":\"1\",\"callbackId\":\"7\"}\n"},
+			url:      "https://api.example.com/v2/users",
+		},
+		{
+			expected: []string{"{\"name\":\"" + EventNameSessionEventInterceptedRequestPassthrough + "\",\"targetID\":\"1\",\"callbackId\":\"7\"}\n"},
+			url:      "http://fixtures.example.com/users/2.json",
+		},
+		{
+			expected: []string{
+				"{\"name\":\"" + EventNameProtocolEventRequestCallback + "\",\"targetID\":\"1\",\"callbackId\":\"7\",\"headers\":{\"Content-Type\":[\"text/plain; charset=utf-8\"],\"X-Content-Type-Options\":[\"nosniff\"]},\"statusCode\":500}\n",
+				"{\"name\":\"" + EventNameProtocolEventRequestCallbackData + "\",\"targetID\":\"1\",\"bytes\":\"SW50ZXJuYWwgU2VydmVyIEVycm9yCg==\",\"callbackId\":\"7\"}\n",
+				"{\"name\":\"" + EventNameProtocolEventRequestCallbackEnd + "\",\"targetID\":\"1\",\"callbackId\":\"7\"}\n",
+			},
+			url: "https://panic.example.com/",
+		},
+	} {
+		wrt.w = []string{}
+		wrt.wg = &sync.WaitGroup{}
+		wrt.wg.Add(len(v.expected))
+		d.dispatch(Event{CallbackID: "7", Name: EventNameSessionEventInterceptedRequest, Request: &EventRequest{URL: v.url}, TargetID: s.id})
+		wrt.wg.Wait()
+		assert.Equal(t, v.expected, wrt.w, v.url)
+	}
+
+	// Fixture directory
+	wrt.w = []string{}
+	wrt.wg.Add(3)
+	d.dispatch(Event{CallbackID: "8", Name: EventNameSessionEventInterceptedRequest, Request: &EventRequest{URL: "http://fixtures.example.com/users/1.json"}, TargetID: s.id})
+	wrt.wg.Wait()
+	assert.Len(t, wrt.w, 3)
+	assert.Contains(t, wrt.w[0], "\"statusCode\":200")
+	assert.Contains(t, wrt.w[1], "\"bytes\":\"eyJpZCI6MX0=\"")
+	wrt.wg = nil
+
+	// Unmock
+	assert.Error(t, s.Unmock(100))
+	wrt.w = []string{}
+	assert.NoError(t, s.Unmock(idPanic))
+	assert.Empty(t, wrt.w)
+	wrt.w = []string{}
+	wrt.fn = func() {
+		d.dispatch(Event{CallbackID: "4", Name: EventNameSessionEventUninterceptedProtocol, TargetID: s.id})
+	}
+	assert.NoError(t, s.Unmock(idDir))
+	wrt.fn = nil
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameSessionCmdUninterceptProtocol + "\",\"targetID\":\"1\",\"callbackId\":\"4\",\"scheme\":\"http\"}\n"}, wrt.w)
+	testObjectRequest(t, func() error { return s.Unmock(id) }, s.object, wrt, "{\"name\":\""+EventNameSessionCmdUninterceptProtocol+"\",\"targetID\":\"1\",\"callbackId\":\"5\",\"scheme\":\"https\"}\n", EventNameSessionEventUninterceptedProtocol, Event{})
+
+	// Schemes intercepted before a failure are unintercepted
+	wrt.w = []string{}
+	wrt.fn = func() {
+		var e Event
+		json.Unmarshal([]byte(wrt.w[len(wrt.w)-1]), &e)
+		switch {
+		case e.Name == EventNameSessionCmdUninterceptProtocol:
+			d.dispatch(Event{CallbackID: e.CallbackID, Name: EventNameSessionEventUninterceptedProtocol, TargetID: s.id})
+		case e.Scheme == "https":
+			d.dispatch(Event{CallbackID: e.CallbackID, Error: "intercept failed", Name: EventNameSessionEventInterceptedProtocol, TargetID: s.id})
+		default:
+			d.dispatch(Event{CallbackID: e.CallbackID, Name: EventNameSessionEventInterceptedProtocol, TargetID: s.id})
+		}
+	}
+	_, err = s.Mock("*://api.example.com/*", http.NotFoundHandler())
+	wrt.fn = nil
+	assert.Error(t, err)
+	assert.Equal(t, []string{
+		"{\"name\":\"" + EventNameSessionCmdInterceptProtocol + "\",\"targetID\":\"1\",\"callbackId\":\"6\",\"scheme\":\"http\"}\n",
+		"{\"name\":\"" + EventNameSessionCmdInterceptProtocol + "\",\"targetID\":\"1\",\"callbackId\":\"7\",\"scheme\":\"https\"}\n",
+		"{\"name\":\"" + EventNameSessionCmdUninterceptProtocol + "\",\"targetID\":\"1\",\"callbackId\":\"8\",\"scheme\":\"http\"}\n",
+	}, wrt.w)
+	assert.Empty(t, s.mocks.schemes)
+
+	// Mocks wait for their schemes being intercepted for other mocks
+	wrt.w = []string{}
+	intercepting, intercepted := make(chan bool), make(chan bool)
+	wrt.fn = func() {
+		var e Event
+		json.Unmarshal([]byte(wrt.w[len(wrt.w)-1]), &e)
+		intercepting <- true
+		<-intercepted
+		d.dispatch(Event{CallbackID: e.CallbackID, Name: EventNameSessionEventInterceptedProtocol, TargetID: s.id})
+	}
+	errs := make(chan error, 2)
+	go func() {
+		_, err := s.Mock("https://a.example.com/*", http.NotFoundHandler())
+		errs <- err
+	}()
+	<-intercepting
+	go func() {
+		_, err := s.Mock("https://b.example.com/*", http.NotFoundHandler())
+		errs <- err
+	}()
+	select {
+	case <-errs:
+		assert.Fail(t, "mock returned before its scheme was intercepted")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(intercepted)
+	assert.NoError(t, <-errs)
+	assert.NoError(t, <-errs)
+	assert.Len(t, wrt.w, 1)
+}
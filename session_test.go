@@ -3,6 +3,10 @@ package astilectron
 import (
 	"context"
 	"testing"
+	"testing/fstest"
+
+	"github.com/asticode/go-astikit"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestSession_Actions(t *testing.T) {
@@ -11,9 +15,59 @@ func TestSession_Actions(t *testing.T) {
 	var i = newIdentifier()
 	var wrt = &mockedWriter{}
 	var w = newWriter(wrt, &logger{})
-	var s = newSession(context.Background(), d, i, w)
+	var s = newSession(context.Background(), &logger{}, d, i, w)
 
 	// Actions
 	testObjectAction(t, func() error { return s.ClearCache() }, s.object, wrt, "{\"name\":\"session.cmd.clear.cache\",\"targetID\":\"1\"}\n", EventNameSessionEventClearedCache)
 	testObjectAction(t, func() error { return s.FlushStorage() }, s.object, wrt, "{\"name\":\"session.cmd.flush.storage\",\"targetID\":\"1\"}\n", EventNameSessionEventFlushedStorage)
+	testObjectRequest(t, func() error {
+		return s.ClearStorageData(context.Background(), ClearStorageDataOptions{Origin: "https://example.com", Storages: []string{SessionStorageLocalStorage, SessionStorageServiceWorkers}})
+	}, s.object, wrt, "{\"name\":\"session.cmd.clear.storage.data\",\"targetID\":\"1\",\"callbackId\":\"2\",\"clearStorageDataOptions\":{\"origin\":\"https://example.com\",\"storages\":[\"localstorage\",\"serviceworkers\"]}}\n", EventNameSessionEventClearedStorageData, Event{})
+	testObjectRequest(t, func() error { return s.ClearAuthCache(context.Background()) }, s.object, wrt, "{\"name\":\"session.cmd.clear.auth.cache\",\"targetID\":\"1\",\"callbackId\":\"3\"}\n", EventNameSessionEventClearedAuthCache, Event{})
+	var size int
+	testObjectRequest(t, func() (err error) {
+		size, err = s.GetCacheSize(context.Background())
+		return
+	}, s.object, wrt, "{\"name\":\"session.cmd.get.cache.size\",\"targetID\":\"1\",\"callbackId\":\"4\"}\n", EventNameSessionEventGetCacheSize, Event{CacheSize: astikit.IntPtr(1024)})
+	assert.Equal(t, 1024, size)
+
+	// Cancelled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.EqualError(t, s.ClearAuthCache(ctx), context.Canceled.Error())
+}
+
+func TestAstilectron_SessionFromPartition(t *testing.T) {
+	// Init
+	a, err := New(nil, Options{})
+	assert.NoError(t, err)
+	defer a.Close()
+	wrt := &mockedWriter{}
+	a.writer = newWriter(wrt, &logger{})
+	wrt.fn = func() {
+		a.dispatcher.dispatch(Event{Name: EventNameSessionEventFromPartition, TargetID: "1"})
+		a.dispatcher.dispatch(Event{Name: EventNameProtocolEventHandled, TargetID: "1"})
+	}
+
+	// Sessions are cached per partition
+	s1, err := a.SessionFromPartition("persist:test")
+	assert.NoError(t, err)
+	assert.Equal(t, "persist:test", s1.Partition())
+	s2, err := a.SessionFromPartition("persist:test")
+	assert.NoError(t, err)
+	assert.Same(t, s1, s2)
+	assert.Equal(t, []string{"{\"name\":\"session.cmd.from.partition\",\"partition\":\"persist:test\",\"sessionId\":\"1\"}\n"}, wrt.w)
+
+	// Windows use the session, and their fs.FS is served in it
+	wrt.w = []string{}
+	w, err := a.NewWindow("index.html", &WindowOptions{FS: fstest.MapFS{}, Session: s1})
+	assert.NoError(t, err)
+	assert.Same(t, s1, w.Session)
+	assert.Equal(t, astikit.StrPtr("1"), w.o.WebPreferences.Session)
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameProtocolCmdHandle + "\",\"targetID\":\"1\",\"scheme\":\"" + FSScheme + "\"}\n"}, wrt.w)
+
+	// Browser views use the session
+	b, err := a.NewBrowserView("", &WindowOptions{}, s1)
+	assert.NoError(t, err)
+	assert.Equal(t, astikit.StrPtr("1"), b.o.WebPreferences.Session)
 }
@@ -0,0 +1,198 @@
+package astilectron
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+)
+
+// JavaScriptError represents an exception thrown by evaluated JavaScript
+type JavaScriptError struct {
+	Message string `json:"message"`
+	Name    string `json:"name,omitempty"`
+	Stack   string `json:"stack,omitempty"`
+}
+
+// Error implements the error interface
+func (e JavaScriptError) Error() string {
+	if e.Name == "" {
+		return "javascript error: " + e.Message
+	}
+	return "javascript error: " + e.Name + ": " + e.Message
+}
+
+// javaScriptResult represents the JSON result of evaluated JavaScript
+type javaScriptResult struct {
+	Error  *JavaScriptError `json:"error,omitempty"`
+	Result json.RawMessage  `json:"result,omitempty"`
+}
+
+// evaluateJavaScript evaluates a JS expression in web contents and decodes its JSON serialized result into out,
+// unless out is nil
+// If the expression returns a promise, its resolved value is used. Exceptions are returned as *JavaScriptError.
+func evaluateJavaScript(ctx context.Context, o *object, cmdName, eventName string, worldID *int, code string, out interface{}) (err error) {
+	// Wrap code
+	// A new line is added after the code so that it can end with a comment
+	code = `(async () => {
+try {
+	const v = await (` + code + `
+	);
+	return JSON.stringify({result: v === undefined ? null : v});
+} catch (e) {
+	return JSON.stringify({error: e instanceof Error ? {message: e.message, name: e.name, stack: e.stack} : {message: String(e)}});
+}
+})()`
+
+	// Execute
+	var e Event
+	if e, err = synchronousRequest(ctx, o, Event{Code: code, Name: cmdName, TargetID: o.id, WorldID: worldID}, eventName); err != nil {
+		err = fmt.Errorf("executing javascript failed: %w", err)
+		return
+	}
+
+	// Unmarshal result
+	var r javaScriptResult
+	if err = json.Unmarshal([]byte(e.CodeResult), &r); err != nil {
+		err = fmt.Errorf("unmarshaling %s failed: %w", e.CodeResult, err)
+		return
+	}
+
+	// Exception
+	if r.Error != nil {
+		err = r.Error
+		return
+	}
+
+	// Decode
+	if out == nil || len(r.Result) == 0 {
+		return
+	}
+	if err = json.Unmarshal(r.Result, out); err != nil {
+		err = fmt.Errorf("unmarshaling %s failed: %w", r.Result, err)
+		return
+	}
+	return
+}
+
+// PDF margins types
+const (
+	PrintToPDFMarginsTypeDefault = 0
+	PrintToPDFMarginsTypeNone    = 1
+	PrintToPDFMarginsTypeMinimum = 2
+)
+
+// PrintToPDFOptions represents print to PDF options
+// PageRanges are 0-based and ScaleFactor is a percentage ranging from 0 to 100.
+// https://github.com/electron/electron/blob/v11.4.3/docs/api/web-contents.md#contentsprinttopdfoptions
+type PrintToPDFOptions struct {
+	HeaderFooter       *PrintToPDFHeaderFooter `json:"headerFooter,omitempty"`
+	Landscape          bool                    `json:"landscape,omitempty"`
+	MarginsType        *int                    `json:"marginsType,omitempty"`
+	PageRanges         *PrintToPDFPageRanges   `json:"pageRanges,omitempty"`
+	PageSize           *PrintToPDFPageSize     `json:"pageSize,omitempty"`
+	PrintBackground    bool                    `json:"printBackground,omitempty"`
+	PrintSelectionOnly bool                    `json:"printSelectionOnly,omitempty"`
+	ScaleFactor        *int                    `json:"scaleFactor,omitempty"`
+}
+
+// PrintToPDFHeaderFooter represents the header and footer of a PDF
+// Setting it makes Electron print the title in the header and the url in the footer.
+type PrintToPDFHeaderFooter struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// PrintToPDFPageRanges represents the range of pages to print to PDF
+type PrintToPDFPageRanges struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// PrintToPDFPageSize represents a PDF page size
+// Either Name (e.g. "A4", "Letter") or Height and Width, in microns, must be set
+type PrintToPDFPageSize struct {
+	Height int
+	Name   string
+	Width  int
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (s PrintToPDFPageSize) MarshalJSON() ([]byte, error) {
+	if s.Name != "" {
+		return json.Marshal(s.Name)
+	}
+	return json.Marshal(map[string]int{"height": s.Height, "width": s.Width})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (s *PrintToPDFPageSize) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &s.Name); err == nil {
+		return nil
+	}
+	var v struct {
+		Height int `json:"height"`
+		Width  int `json:"width"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	s.Height, s.Width = v.Height, v.Width
+	return nil
+}
+
+// capturePage captures a snapshot of web contents as PNG bytes
+// A nil rect means the whole visible page is captured.
+func capturePage(ctx context.Context, o *object, cmdName, eventName string, rect *RectangleOptions) (b []byte, err error) {
+	// Capture
+	var e Event
+	if e, err = synchronousRequest(ctx, o, Event{Bounds: rect, Name: cmdName, TargetID: o.id}, eventName); err != nil {
+		err = fmt.Errorf("capturing page failed: %w", err)
+		return
+	}
+
+	// Empty image
+	if len(e.Bytes) == 0 {
+		err = errors.New("captured page is empty")
+		return
+	}
+	b = e.Bytes
+	return
+}
+
+// capturePageImage captures a snapshot of web contents as an image
+func capturePageImage(ctx context.Context, o *object, cmdName, eventName string, rect *RectangleOptions) (i image.Image, err error) {
+	// Capture
+	var b []byte
+	if b, err = capturePage(ctx, o, cmdName, eventName, rect); err != nil {
+		return
+	}
+
+	// Decode
+	if i, err = png.Decode(bytes.NewReader(b)); err != nil {
+		err = fmt.Errorf("decoding png failed: %w", err)
+		return
+	}
+	return
+}
+
+// printToPDF prints web contents as PDF bytes
+func printToPDF(ctx context.Context, o *object, cmdName, eventName string, po PrintToPDFOptions) (b []byte, err error) {
+	// Print
+	var e Event
+	if e, err = synchronousRequest(ctx, o, Event{Name: cmdName, PrintToPDFOptions: &po, TargetID: o.id}, eventName); err != nil {
+		err = fmt.Errorf("printing to pdf failed: %w", err)
+		return
+	}
+
+	// Empty pdf
+	if len(e.Bytes) == 0 {
+		err = errors.New("printed pdf is empty")
+		return
+	}
+	b = e.Bytes
+	return
+}
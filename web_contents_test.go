@@ -0,0 +1,145 @@
+package astilectron
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/asticode/go-astikit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateJavaScript(t *testing.T) {
+	// Init
+	a, err := New(nil, Options{})
+	assert.NoError(t, err)
+	defer a.Close()
+	wrt := &mockedWriter{}
+	a.writer = newWriter(wrt, &logger{})
+	w, err := a.NewWindow("http://test.com", &WindowOptions{})
+	assert.NoError(t, err)
+	b, err := a.NewBrowserView("", &WindowOptions{}, nil)
+	assert.NoError(t, err)
+	var sent []Event
+	var codeResult string
+	wrt.fn = func() {
+		var e Event
+		json.Unmarshal([]byte(wrt.w[len(wrt.w)-1]), &e)
+		sent = append(sent, e)
+		n := EventNameWindowEventWebContentsExecutedJavaScript
+		switch e.Name {
+		case EventNameBrowserViewCmdWebContentsExecuteJavaScriptInIsolatedWorld:
+			n = EventNameBrowserViewEventWebContentsExecutedJavaScriptInIsolatedWorld
+		}
+		w.d.dispatch(Event{CallbackID: e.CallbackID, CodeResult: codeResult, Name: n, TargetID: e.TargetID})
+	}
+
+	// Typed result
+	codeResult = "{\"result\":{\"title\":\"Test\",\"count\":2}}"
+	var r struct {
+		Count int    `json:"count"`
+		Title string `json:"title"`
+	}
+	assert.NoError(t, w.Evaluate(context.Background(), "fetch('/api').then(r => r.json()) // comment", &r))
+	assert.Equal(t, 2, r.Count)
+	assert.Equal(t, "Test", r.Title)
+	assert.Equal(t, EventNameWindowCmdWebContentsExecuteJavaScript, sent[0].Name)
+	assert.Equal(t, w.id, sent[0].TargetID)
+	assert.Contains(t, sent[0].Code, "await (fetch('/api').then(r => r.json()) // comment\n")
+	assert.Nil(t, sent[0].WorldID)
+
+	// Isolated world
+	codeResult = "{\"result\":null}"
+	var s *string
+	assert.NoError(t, b.EvaluateInIsolatedWorld(context.Background(), 1000, "undefined", &s))
+	assert.Nil(t, s)
+	assert.Equal(t, EventNameBrowserViewCmdWebContentsExecuteJavaScriptInIsolatedWorld, sent[1].Name)
+	assert.Equal(t, astikit.IntPtr(1000), sent[1].WorldID)
+
+	// Exception
+	codeResult = "{\"error\":{\"message\":\"x is not defined\",\"name\":\"ReferenceError\",\"stack\":\"ReferenceError: x is not defined\\n    at <anonymous>:1:1\"}}"
+	err = w.Evaluate(context.Background(), "x", nil)
+	assert.EqualError(t, err, "javascript error: ReferenceError: x is not defined")
+	var jsErr *JavaScriptError
+	assert.True(t, errors.As(err, &jsErr))
+	assert.Equal(t, "ReferenceError: x is not defined\n    at <anonymous>:1:1", jsErr.Stack)
+
+	// Invalid result
+	codeResult = "invalid"
+	assert.Error(t, w.Evaluate(context.Background(), "1", nil))
+
+	// Cancelled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.EqualError(t, w.Evaluate(ctx, "1", nil), "executing javascript failed: context canceled")
+}
+
+func TestCapturePageAndPrintToPDF(t *testing.T) {
+	// Init
+	a, err := New(nil, Options{})
+	assert.NoError(t, err)
+	defer a.Close()
+	wrt := &mockedWriter{}
+	a.writer = newWriter(wrt, &logger{})
+	w, err := a.NewWindow("http://test.com", &WindowOptions{})
+	assert.NoError(t, err)
+	b, err := a.NewBrowserView("", &WindowOptions{}, nil)
+	assert.NoError(t, err)
+	buf := &bytes.Buffer{}
+	assert.NoError(t, png.Encode(buf, image.NewRGBA(image.Rect(0, 0, 2, 1))))
+	img := buf.Bytes()
+	var sent []string
+	var reply []byte
+	wrt.fn = func() {
+		var e Event
+		json.Unmarshal([]byte(wrt.w[len(wrt.w)-1]), &e)
+		sent = append(sent, wrt.w[len(wrt.w)-1])
+		n := map[string]string{
+			EventNameBrowserViewCmdWebContentsPrintToPDF: EventNameBrowserViewEventWebContentsPrintedToPDF,
+			EventNameWindowCmdWebContentsCapturePage:     EventNameWindowEventWebContentsCapturedPage,
+		}[e.Name]
+		w.d.dispatch(Event{Bytes: reply, CallbackID: e.CallbackID, Name: n, TargetID: e.TargetID})
+	}
+
+	// Capture page
+	reply = img
+	p, err := w.CapturePage(context.Background(), &RectangleOptions{SizeOptions: SizeOptions{Height: astikit.IntPtr(1), Width: astikit.IntPtr(2)}})
+	assert.NoError(t, err)
+	assert.Equal(t, img, p)
+	assert.Equal(t, "{\"name\":\""+EventNameWindowCmdWebContentsCapturePage+"\",\"targetID\":\""+w.id+"\",\"bounds\":{\"height\":1,\"width\":2},\"callbackId\":\"4\"}\n", sent[0])
+	i, err := w.CapturePageImage(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 2, 1), i.Bounds())
+	reply = nil
+	_, err = w.CapturePage(context.Background(), nil)
+	assert.EqualError(t, err, "captured page is empty")
+
+	// Print to PDF
+	reply = []byte("%PDF")
+	pdf, err := b.PrintToPDF(context.Background(), PrintToPDFOptions{
+		HeaderFooter: &PrintToPDFHeaderFooter{Title: "title", URL: "http://test.com"},
+		Landscape:    true,
+		MarginsType:  astikit.IntPtr(PrintToPDFMarginsTypeNone),
+		PageRanges:   &PrintToPDFPageRanges{From: 0, To: 1},
+		PageSize:     &PrintToPDFPageSize{Name: "A4"},
+		ScaleFactor:  astikit.IntPtr(50),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("%PDF"), pdf)
+	assert.Equal(t, "{\"name\":\""+EventNameBrowserViewCmdWebContentsPrintToPDF+"\",\"targetID\":\""+b.id+"\",\"callbackId\":\"7\",\"printToPDFOptions\":{\"headerFooter\":{\"title\":\"title\",\"url\":\"http://test.com\"},\"landscape\":true,\"marginsType\":1,\"pageRanges\":{\"from\":0,\"to\":1},\"pageSize\":\"A4\",\"scaleFactor\":50}}\n", sent[3])
+	reply = nil
+	_, err = b.PrintToPDF(context.Background(), PrintToPDFOptions{})
+	assert.EqualError(t, err, "printed pdf is empty")
+
+	// Custom page size
+	var s PrintToPDFPageSize
+	assert.NoError(t, json.Unmarshal([]byte("{\"height\":297000,\"width\":210000}"), &s))
+	assert.Equal(t, PrintToPDFPageSize{Height: 297000, Width: 210000}, s)
+	b2, err := json.Marshal(s)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"height\":297000,\"width\":210000}", string(b2))
+}
@@ -0,0 +1,118 @@
+package astilectron
+
+// Web request resource types
+const (
+	WebRequestResourceTypeCSPReport  = "cspReport"
+	WebRequestResourceTypeFont       = "font"
+	WebRequestResourceTypeImage      = "image"
+	WebRequestResourceTypeMainFrame  = "mainFrame"
+	WebRequestResourceTypeMedia      = "media"
+	WebRequestResourceTypeObject     = "object"
+	WebRequestResourceTypeOther      = "other"
+	WebRequestResourceTypePing       = "ping"
+	WebRequestResourceTypeScript     = "script"
+	WebRequestResourceTypeStylesheet = "stylesheet"
+	WebRequestResourceTypeSubFrame   = "subFrame"
+	WebRequestResourceTypeWebSocket  = "webSocket"
+	WebRequestResourceTypeXHR        = "xhr"
+)
+
+// WebRequestDetails represents the details of a web request
+// Depending on the lifecycle event, some attributes may be empty
+// https://github.com/electron/electron/blob/v11.4.3/docs/api/web-request.md
+type WebRequestDetails struct {
+	Error           string                 `json:"error,omitempty"`
+	FromCache       bool                   `json:"fromCache,omitempty"`
+	ID              int                    `json:"id"`
+	IP              string                 `json:"ip,omitempty"`
+	Method          string                 `json:"method,omitempty"`
+	Referrer        string                 `json:"referrer,omitempty"`
+	RequestHeaders  map[string]string      `json:"requestHeaders,omitempty"`
+	ResourceType    string                 `json:"resourceType,omitempty"`
+	ResponseHeaders map[string][]string    `json:"responseHeaders,omitempty"`
+	StatusCode      int                    `json:"statusCode,omitempty"`
+	StatusLine      string                 `json:"statusLine,omitempty"`
+	Timestamp       float64                `json:"timestamp,omitempty"`
+	UploadData      []WebRequestUploadData `json:"uploadData,omitempty"`
+	URL             string                 `json:"url,omitempty"`
+	WebContentsID   *int                   `json:"webContentsId,omitempty"`
+}
+
+// WebRequestUploadData represents the upload data of a web request, which is only available on onBeforeRequest
+// https://github.com/electron/electron/blob/v11.4.3/docs/api/structures/upload-data.md
+type WebRequestUploadData struct {
+	BlobUUID string `json:"blobUUID,omitempty"`
+	Bytes    []byte `json:"bytes,omitempty"`
+	File     string `json:"file,omitempty"`
+}
+
+// WebRequestBeforeSendHeadersResponse represents the response to an onBeforeSendHeaders event
+// A nil RequestHeaders leaves the request headers untouched
+type WebRequestBeforeSendHeadersResponse struct {
+	Cancel         bool
+	RequestHeaders map[string]string
+}
+
+// WebRequestHeadersReceivedResponse represents the response to an onHeadersReceived event
+// A nil ResponseHeaders leaves the response headers untouched, and an empty StatusLine leaves the status line untouched
+type WebRequestHeadersReceivedResponse struct {
+	Cancel          bool
+	ResponseHeaders map[string][]string
+	StatusLine      string
+}
+
+// OnBeforeSendHeaders lets Go modify the headers of requests matching the filter before they're sent, or cancel them
+// It adds a handler to the session's web request router
+func (s *Session) OnBeforeSendHeaders(filter FilterOptions, fn func(d WebRequestDetails) WebRequestBeforeSendHeadersResponse) (err error) {
+	_, err = s.webRequest.OnBeforeSendHeaders(WebRequestHandlerOptions{Patterns: filter.Urls}, fn)
+	return
+}
+
+// OnHeadersReceived lets Go modify the headers of responses matching the filter once they're received, e.g. to
+// inject a Content-Security-Policy, or cancel them
+// It adds a handler to the session's web request router
+func (s *Session) OnHeadersReceived(filter FilterOptions, fn func(d WebRequestDetails) WebRequestHeadersReceivedResponse) (err error) {
+	_, err = s.webRequest.OnHeadersReceived(WebRequestHandlerOptions{Patterns: filter.Urls}, fn)
+	return
+}
+
+// OnResponseStarted executes a callback when the first byte of the body of responses matching the filter is received
+// It adds a handler to the session's web request router
+func (s *Session) OnResponseStarted(filter FilterOptions, fn func(d WebRequestDetails)) (err error) {
+	_, err = s.webRequest.OnResponseStarted(WebRequestHandlerOptions{Patterns: filter.Urls}, fn)
+	return
+}
+
+// OnCompleted executes a callback when requests matching the filter are completed
+// It adds a handler to the session's web request router
+func (s *Session) OnCompleted(filter FilterOptions, fn func(d WebRequestDetails)) (err error) {
+	_, err = s.webRequest.OnCompleted(WebRequestHandlerOptions{Patterns: filter.Urls}, fn)
+	return
+}
+
+// OnErrorOccurred executes a callback when requests matching the filter fail
+// It adds a handler to the session's web request router
+func (s *Session) OnErrorOccurred(filter FilterOptions, fn func(d WebRequestDetails)) (err error) {
+	_, err = s.webRequest.OnErrorOccurred(WebRequestHandlerOptions{Patterns: filter.Urls}, fn)
+	return
+}
+
+// WebRequest returns the session's web request router
+func (s *Session) WebRequest() *WebRequestRouter {
+	return s.webRequest
+}
+
+// webRequestDetails returns the web request details of an event
+func webRequestDetails(e Event) (d WebRequestDetails) {
+	if e.WebRequest != nil {
+		d = *e.WebRequest
+	} else if e.Request != nil {
+		d = WebRequestDetails{
+			Method:         e.Request.Method,
+			Referrer:       e.Request.Referrer,
+			RequestHeaders: e.Request.Headers,
+			URL:            e.Request.URL,
+		}
+	}
+	return
+}
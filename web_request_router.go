@@ -0,0 +1,534 @@
+package astilectron
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// webRequestAllURLs is the match pattern matching all URLs
+const webRequestAllURLs = "<all_urls>"
+
+// Web request off event names
+const (
+	EventNameSessionCmdWebRequestOffBeforeRequest     = "session.cmd.web.request.off.before.request"
+	EventNameSessionCmdWebRequestOffBeforeSendHeaders = "session.cmd.web.request.off.before.send.headers"
+	EventNameSessionCmdWebRequestOffCompleted         = "session.cmd.web.request.off.completed"
+	EventNameSessionCmdWebRequestOffErrorOccurred     = "session.cmd.web.request.off.error.occurred"
+	EventNameSessionCmdWebRequestOffHeadersReceived   = "session.cmd.web.request.off.headers.received"
+	EventNameSessionCmdWebRequestOffResponseStarted   = "session.cmd.web.request.off.response.started"
+)
+
+// WebRequestHandlerOptions represents web request handler options
+// Patterns use Chrome's match pattern syntax (e.g. "https://*.example.com/api/*") and are evaluated in GO. No pattern
+// means all URLs are matched.
+// Handlers with a higher Priority are executed first. Handlers with the same Priority are executed in the order they
+// were added.
+// https://developer.chrome.com/docs/extensions/mv3/match_patterns/
+type WebRequestHandlerOptions struct {
+	Patterns []string
+	Priority int
+}
+
+// WebRequestBeforeRequestResponse represents the response to an onBeforeRequest event
+type WebRequestBeforeRequestResponse struct {
+	Cancel      bool
+	RedirectURL string
+}
+
+// WebRequestRouter represents an object capable of routing the web requests of a session to many handlers
+// Electron only allows one listener per web request event and per session: the router registers a single listener
+// whose filter is the union of its handlers' patterns, and combines its handlers' decisions deterministically:
+//   - handlers are executed by priority and the first one cancelling the request wins, skipping the others
+//   - on onBeforeRequest, the redirection of the handler with the highest priority wins
+//   - on onBeforeSendHeaders and onHeadersReceived, handlers are chained: each handler receives the headers as
+//     modified by the previous handlers
+type WebRequestRouter struct {
+	id     int // Last handler id
+	m      sync.Mutex
+	s      *Session
+	stages map[string]*webRequestStage // Indexed by event name
+}
+
+// webRequestStage represents a web request lifecycle event
+type webRequestStage struct {
+	callbackName string
+	cmdName      string
+	eventName    string
+	filter       *FilterOptions // Union of the handlers' patterns, nil if Electron shouldn't forward the stage's events
+	hs           []*webRequestHandler
+	listening    bool
+	offCmdName   string
+	sent         *FilterOptions // Last filter sent to Electron, nil if Electron doesn't forward the stage's events
+	wm           sync.Mutex     // Locks sent and serializes the filter writes
+}
+
+// webRequestHandler represents a web request handler
+type webRequestHandler struct {
+	fn       webRequestHandlerFunc
+	id       int
+	patterns []*webRequestPattern
+	priority int
+	raw      []string
+}
+
+// webRequestHandlerFunc represents a web request handler func updating the decision in place
+type webRequestHandlerFunc func(e Event, d WebRequestDetails, dc *webRequestDecision) (deleteHandler bool)
+
+// webRequestDecision represents the combined decision of a web request stage's handlers
+type webRequestDecision struct {
+	cancel          bool
+	redirectURL     string
+	requestHeaders  map[string]string
+	responseHeaders map[string][]string
+	statusLine      string
+}
+
+// newWebRequestRouter creates a new web request router
+func newWebRequestRouter(s *Session) *WebRequestRouter {
+	r := &WebRequestRouter{
+		s:      s,
+		stages: make(map[string]*webRequestStage),
+	}
+	for _, st := range []*webRequestStage{
+		{callbackName: EventNameSessionEventWebRequestOnBeforeRequestCallback, cmdName: EventNameSessionCmdWebRequestOnBeforeRequest, eventName: EventNameSessionEventWebRequestOnBeforeRequest, offCmdName: EventNameSessionCmdWebRequestOffBeforeRequest},
+		{callbackName: EventNameSessionEventWebRequestOnBeforeSendHeadersCallback, cmdName: EventNameSessionCmdWebRequestOnBeforeSendHeaders, eventName: EventNameSessionEventWebRequestOnBeforeSendHeaders, offCmdName: EventNameSessionCmdWebRequestOffBeforeSendHeaders},
+		{callbackName: EventNameSessionEventWebRequestOnHeadersReceivedCallback, cmdName: EventNameSessionCmdWebRequestOnHeadersReceived, eventName: EventNameSessionEventWebRequestOnHeadersReceived, offCmdName: EventNameSessionCmdWebRequestOffHeadersReceived},
+		{cmdName: EventNameSessionCmdWebRequestOnResponseStarted, eventName: EventNameSessionEventWebRequestOnResponseStarted, offCmdName: EventNameSessionCmdWebRequestOffResponseStarted},
+		{cmdName: EventNameSessionCmdWebRequestOnCompleted, eventName: EventNameSessionEventWebRequestOnCompleted, offCmdName: EventNameSessionCmdWebRequestOffCompleted},
+		{cmdName: EventNameSessionCmdWebRequestOnErrorOccurred, eventName: EventNameSessionEventWebRequestOnErrorOccurred, offCmdName: EventNameSessionCmdWebRequestOffErrorOccurred},
+	} {
+		r.stages[st.eventName] = st
+	}
+	return r
+}
+
+// OnBeforeRequest adds a handler that can cancel or redirect requests before they're made
+func (r *WebRequestRouter) OnBeforeRequest(o WebRequestHandlerOptions, fn func(d WebRequestDetails) WebRequestBeforeRequestResponse) (id int, err error) {
+	return r.add(EventNameSessionEventWebRequestOnBeforeRequest, o, func(e Event, d WebRequestDetails, dc *webRequestDecision) bool {
+		res := fn(d)
+		dc.cancel = res.Cancel
+		if dc.redirectURL == "" {
+			dc.redirectURL = res.RedirectURL
+		}
+		return false
+	})
+}
+
+// OnBeforeSendHeaders adds a handler that can modify the headers of requests before they're sent, or cancel them
+func (r *WebRequestRouter) OnBeforeSendHeaders(o WebRequestHandlerOptions, fn func(d WebRequestDetails) WebRequestBeforeSendHeadersResponse) (id int, err error) {
+	return r.add(EventNameSessionEventWebRequestOnBeforeSendHeaders, o, func(e Event, d WebRequestDetails, dc *webRequestDecision) bool {
+		if dc.requestHeaders != nil {
+			d.RequestHeaders = dc.requestHeaders
+		}
+		d.RequestHeaders = copyRequestHeaders(d.RequestHeaders)
+		res := fn(d)
+		dc.cancel = res.Cancel
+		if res.RequestHeaders != nil {
+			dc.requestHeaders = res.RequestHeaders
+		}
+		return false
+	})
+}
+
+// OnHeadersReceived adds a handler that can modify the headers of responses once they're received, or cancel them
+func (r *WebRequestRouter) OnHeadersReceived(o WebRequestHandlerOptions, fn func(d WebRequestDetails) WebRequestHeadersReceivedResponse) (id int, err error) {
+	return r.add(EventNameSessionEventWebRequestOnHeadersReceived, o, func(e Event, d WebRequestDetails, dc *webRequestDecision) bool {
+		if dc.responseHeaders != nil {
+			d.ResponseHeaders = dc.responseHeaders
+		}
+		if dc.statusLine != "" {
+			d.StatusLine = dc.statusLine
+		}
+		d.ResponseHeaders = copyResponseHeaders(d.ResponseHeaders)
+		res := fn(d)
+		dc.cancel = res.Cancel
+		if res.ResponseHeaders != nil {
+			dc.responseHeaders = res.ResponseHeaders
+		}
+		if res.StatusLine != "" {
+			dc.statusLine = res.StatusLine
+		}
+		return false
+	})
+}
+
+// OnResponseStarted adds a handler executed when the first byte of the body of responses is received
+func (r *WebRequestRouter) OnResponseStarted(o WebRequestHandlerOptions, fn func(d WebRequestDetails)) (id int, err error) {
+	return r.add(EventNameSessionEventWebRequestOnResponseStarted, o, webRequestObserver(fn))
+}
+
+// OnCompleted adds a handler executed when requests are completed
+func (r *WebRequestRouter) OnCompleted(o WebRequestHandlerOptions, fn func(d WebRequestDetails)) (id int, err error) {
+	return r.add(EventNameSessionEventWebRequestOnCompleted, o, webRequestObserver(fn))
+}
+
+// OnErrorOccurred adds a handler executed when requests fail
+func (r *WebRequestRouter) OnErrorOccurred(o WebRequestHandlerOptions, fn func(d WebRequestDetails)) (id int, err error) {
+	return r.add(EventNameSessionEventWebRequestOnErrorOccurred, o, webRequestObserver(fn))
+}
+
+// Remove removes a handler
+// Once all the handlers of a stage have been removed, Electron stops forwarding its events.
+func (r *WebRequestRouter) Remove(id int) (err error) {
+	// Remove handler
+	r.m.Lock()
+	st, changed, ok := r.del(id)
+	r.m.Unlock()
+	if !ok {
+		err = fmt.Errorf("web request handler %d doesn't exist", id)
+		return
+	}
+
+	// Update filter
+	if changed {
+		err = r.sync(st)
+	}
+	return
+}
+
+// webRequestObserver adapts a func that can't alter the request into a web request handler func
+func webRequestObserver(fn func(d WebRequestDetails)) webRequestHandlerFunc {
+	return func(e Event, d WebRequestDetails, dc *webRequestDecision) bool {
+		fn(d)
+		return false
+	}
+}
+
+// add adds a handler to a stage and makes sure Electron forwards the events matching its patterns
+func (r *WebRequestRouter) add(eventName string, o WebRequestHandlerOptions, fn webRequestHandlerFunc) (id int, err error) {
+	// Check context
+	if err = r.s.ctx.Err(); err != nil {
+		return
+	}
+
+	// Parse patterns
+	h := &webRequestHandler{fn: fn, priority: o.Priority, raw: o.Patterns}
+	for _, raw := range o.Patterns {
+		var p *webRequestPattern
+		if p, err = parseWebRequestPattern(raw); err != nil {
+			err = fmt.Errorf("parsing pattern %s failed: %w", raw, err)
+			return
+		}
+		h.patterns = append(h.patterns, p)
+	}
+
+	// Add handler
+	r.m.Lock()
+	st := r.stages[eventName]
+	r.id++
+	h.id = r.id
+	id = h.id
+	st.hs = append(st.hs, h)
+	sort.SliceStable(st.hs, func(i, j int) bool { return st.hs[i].priority > st.hs[j].priority })
+
+	// Listen
+	if !st.listening {
+		r.s.On(st.eventName, func(e Event) (deleteListener bool) {
+			r.dispatch(st, e)
+			return
+		})
+		st.listening = true
+	}
+
+	// Update filter
+	changed := st.updateFilter()
+	r.m.Unlock()
+	if changed {
+		err = r.sync(st)
+	}
+	return
+}
+
+// del removes a handler and returns its stage
+// It assumes the mutex is locked
+func (r *WebRequestRouter) del(id int) (st *webRequestStage, changed, ok bool) {
+	for _, st = range r.stages {
+		for idx, h := range st.hs {
+			if h.id != id {
+				continue
+			}
+			st.hs = append(st.hs[:idx:idx], st.hs[idx+1:]...)
+			ok = true
+			if len(st.hs) > 0 {
+				changed = st.updateFilter()
+			} else {
+				changed = true
+				st.filter = nil
+			}
+			return
+		}
+	}
+	return
+}
+
+// sync lets Electron know which events of a stage it should forward
+// Writes are serialized and the filter is read once the previous write is done, so that Electron always ends up with
+// the latest filter even when handlers are added and removed concurrently.
+func (r *WebRequestRouter) sync(st *webRequestStage) (err error) {
+	// Lock writes
+	st.wm.Lock()
+	defer st.wm.Unlock()
+
+	// Get latest filter
+	r.m.Lock()
+	f := st.filter
+	r.m.Unlock()
+
+	// Filter has already been sent
+	if f == st.sent || (f != nil && st.sent != nil && reflect.DeepEqual(*f, *st.sent)) {
+		return
+	}
+
+	// Check context
+	if err = r.s.ctx.Err(); err != nil {
+		return
+	}
+
+	// Write
+	if f == nil {
+		err = r.s.w.write(Event{Name: st.offCmdName, TargetID: r.s.id})
+	} else {
+		err = r.s.w.write(Event{Name: st.cmdName, TargetID: r.s.id, Filter: f})
+	}
+	if err != nil {
+		return
+	}
+	st.sent = f
+	return
+}
+
+// dispatch executes the matching handlers of a stage and sends their decision back to Electron
+func (r *WebRequestRouter) dispatch(st *webRequestStage, e Event) {
+	// Copy handlers so that they can be executed without holding the lock
+	r.m.Lock()
+	hs := make([]*webRequestHandler, len(st.hs))
+	copy(hs, st.hs)
+	r.m.Unlock()
+
+	// Loop through handlers
+	d := webRequestDetails(e)
+	u, _ := url.Parse(d.URL)
+	var dc webRequestDecision
+	for _, h := range hs {
+		// Handler doesn't match
+		if !h.matches(u) {
+			continue
+		}
+
+		// Execute handler
+		if h.fn(e, d, &dc) {
+			if err := r.Remove(h.id); err != nil {
+				r.s.l.Error(fmt.Errorf("removing web request handler %d failed: %w", h.id, err))
+			}
+		}
+
+		// First cancel wins
+		if dc.cancel {
+			break
+		}
+	}
+
+	// Stage doesn't expect a callback
+	if st.callbackName == "" {
+		return
+	}
+
+	// Send decision back
+	c := Event{
+		CallbackID:      e.CallbackID,
+		Cancel:          &dc.cancel,
+		Name:            st.callbackName,
+		RequestHeaders:  dc.requestHeaders,
+		ResponseHeaders: dc.responseHeaders,
+		StatusLine:      dc.statusLine,
+		TargetID:        r.s.id,
+	}
+	if !dc.cancel {
+		c.RedirectURL = dc.redirectURL
+	}
+	if err := r.s.w.write(c); err != nil {
+		r.s.l.Error(fmt.Errorf("writing %s event failed: %w", c.Name, err))
+	}
+}
+
+// updateFilter computes the union of the handlers' patterns and returns whether it has changed
+// It assumes the mutex is locked
+func (st *webRequestStage) updateFilter() (changed bool) {
+	// Compute union
+	var f FilterOptions
+	m := make(map[string]bool)
+	for _, h := range st.hs {
+		if len(h.raw) == 0 {
+			f.Urls = nil
+			break
+		}
+		var all bool
+		for _, raw := range h.raw {
+			if raw == webRequestAllURLs {
+				all = true
+				break
+			}
+			if !m[raw] {
+				m[raw] = true
+				f.Urls = append(f.Urls, raw)
+			}
+		}
+		if all {
+			f.Urls = nil
+			break
+		}
+	}
+
+	// Check whether the filter has changed
+	if st.filter != nil && reflect.DeepEqual(*st.filter, f) {
+		return
+	}
+	st.filter = &f
+	changed = true
+	return
+}
+
+// matches checks whether the handler matches a URL
+func (h *webRequestHandler) matches(u *url.URL) bool {
+	if len(h.patterns) == 0 {
+		return true
+	}
+	if u == nil {
+		return false
+	}
+	for _, p := range h.patterns {
+		if p.matches(u) {
+			return true
+		}
+	}
+	return false
+}
+
+// webRequestPattern represents a parsed Chrome match pattern
+type webRequestPattern struct {
+	all       bool
+	host      string
+	path      *regexp.Regexp
+	port      string // Empty or "*" means all ports, default ports of the scheme match URLs without port
+	scheme    string // "*" means http, https, ws and wss
+	subdomain bool   // Whether subdomains of the host are matched as well
+}
+
+// parseWebRequestPattern parses a Chrome match pattern
+func parseWebRequestPattern(raw string) (p *webRequestPattern, err error) {
+	// All URLs
+	p = &webRequestPattern{}
+	if raw == webRequestAllURLs {
+		p.all = true
+		return
+	}
+
+	// Scheme
+	i := strings.Index(raw, "://")
+	if i <= 0 {
+		err = fmt.Errorf("missing scheme separator")
+		return
+	}
+	p.scheme = strings.ToLower(raw[:i])
+	if p.scheme != "*" && strings.Contains(p.scheme, "*") {
+		err = fmt.Errorf("invalid scheme %s", p.scheme)
+		return
+	}
+	raw = raw[i+3:]
+
+	// Host
+	i = strings.Index(raw, "/")
+	if i < 0 {
+		err = fmt.Errorf("missing path")
+		return
+	}
+	host, path := strings.ToLower(raw[:i]), raw[i:]
+	if j := strings.LastIndex(host, ":"); j >= 0 && !strings.HasSuffix(host, "]") {
+		host, p.port = host[:j], host[j+1:]
+	}
+	switch {
+	case host == "*":
+		p.subdomain = true
+	case strings.HasPrefix(host, "*."):
+		p.host = host[2:]
+		p.subdomain = true
+	default:
+		p.host = strings.Trim(host, "[]")
+	}
+	if strings.Contains(p.host, "*") {
+		err = fmt.Errorf("invalid host %s", host)
+		return
+	}
+	if p.host == "" && !p.subdomain && p.scheme != "file" {
+		err = fmt.Errorf("missing host")
+		return
+	}
+
+	// Path
+	p.path = regexp.MustCompile("^" + strings.ReplaceAll(regexp.QuoteMeta(path), `\*`, ".*") + "$")
+	return
+}
+
+// matches checks whether the pattern matches a URL
+func (p *webRequestPattern) matches(u *url.URL) bool {
+	// All URLs
+	if p.all {
+		return true
+	}
+
+	// Scheme
+	s := strings.ToLower(u.Scheme)
+	if p.scheme == "*" {
+		if s != "http" && s != "https" && s != "ws" && s != "wss" {
+			return false
+		}
+	} else if p.scheme != s {
+		return false
+	}
+
+	// Host
+	h := strings.ToLower(u.Hostname())
+	if p.host != "" && h != p.host && (!p.subdomain || !strings.HasSuffix(h, "."+p.host)) {
+		return false
+	} else if p.host == "" && !p.subdomain && h != "" {
+		return false
+	}
+
+	// Port
+	if p.port != "" && p.port != "*" && navigationDefaultPort(s, p.port) != navigationDefaultPort(s, u.Port()) {
+		return false
+	}
+
+	// Path, which includes the query
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return p.path.MatchString(path)
+}
+
+// copyRequestHeaders returns a copy of request headers that handlers can modify freely
+func copyRequestHeaders(i map[string]string) (o map[string]string) {
+	o = make(map[string]string, len(i))
+	for k, v := range i {
+		o[k] = v
+	}
+	return
+}
+
+// copyResponseHeaders returns a copy of response headers that handlers can modify freely
+func copyResponseHeaders(i map[string][]string) (o map[string][]string) {
+	o = make(map[string][]string, len(i))
+	for k, v := range i {
+		o[k] = append([]string(nil), v...)
+	}
+	return
+}
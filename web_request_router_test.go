@@ -0,0 +1,173 @@
+package astilectron
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebRequestPattern(t *testing.T) {
+	for _, c := range []struct {
+		pattern string
+		url     string
+		match   bool
+	}{
+		{pattern: "<all_urls>", url: "file:///tmp/test", match: true},
+		{pattern: "*://*/*", url: "https://example.com/", match: true},
+		{pattern: "*://*/*", url: "wss://example.com/socket", match: true},
+		{pattern: "*://*/*", url: "file:///tmp/test", match: false},
+		{pattern: "https://*.example.com/*", url: "https://example.com/", match: true},
+		{pattern: "https://*.example.com/*", url: "https://api.EXAMPLE.com/v1", match: true},
+		{pattern: "https://*.example.com/*", url: "https://badexample.com/", match: false},
+		{pattern: "https://*.example.com/*", url: "http://api.example.com/", match: false},
+		{pattern: "https://example.com/api/*", url: "https://example.com/api/users?id=1", match: true},
+		{pattern: "https://example.com/api/*", url: "https://example.com/static/app.js", match: false},
+		{pattern: "https://example.com/*?id=*", url: "https://example.com/users?id=1", match: true},
+		{pattern: "https://example.com/", url: "https://example.com", match: true},
+		{pattern: "http://localhost:*/*", url: "http://localhost:8080/", match: true},
+		{pattern: "http://localhost:4000/*", url: "http://localhost:8080/", match: false},
+		{pattern: "https://example.com:443/*", url: "https://example.com/", match: true},
+		{pattern: "https://example.com/*", url: "https://example.com:443/", match: true},
+		{pattern: "*://example.com:80/*", url: "ws://example.com/", match: true},
+		{pattern: "*://example.com:80/*", url: "https://example.com/", match: false},
+		{pattern: "file:///tmp/*", url: "file:///tmp/test", match: true},
+	} {
+		p, err := parseWebRequestPattern(c.pattern)
+		assert.NoError(t, err, c.pattern)
+		u, err := url.Parse(c.url)
+		assert.NoError(t, err)
+		assert.Equal(t, c.match, p.matches(u), "%s with %s", c.pattern, c.url)
+	}
+	for _, pattern := range []string{"example.com", "https://example.com", "ht*p://example.com/", "https://ex*.com/", "https:///"} {
+		_, err := parseWebRequestPattern(pattern)
+		assert.Error(t, err, pattern)
+	}
+}
+
+func TestWebRequestRouter(t *testing.T) {
+	// Init
+	d := newDispatcher()
+	i := newIdentifier()
+	wrt := &mockedWriter{wg: &sync.WaitGroup{}}
+	w := newWriter(wrt, &logger{})
+	s := newSession(context.Background(), &logger{}, d, i, w)
+	r := s.WebRequest()
+
+	// Invalid pattern
+	_, err := r.OnBeforeRequest(WebRequestHandlerOptions{Patterns: []string{"invalid"}}, nil)
+	assert.Error(t, err)
+
+	// Handlers are merged into a single registration
+	wrt.wg.Add(2)
+	_, err = r.OnBeforeRequest(WebRequestHandlerOptions{Patterns: []string{"https://*.example.com/*"}}, func(d WebRequestDetails) WebRequestBeforeRequestResponse {
+		return WebRequestBeforeRequestResponse{RedirectURL: "https://low.example.com/"}
+	})
+	assert.NoError(t, err)
+	id, err := r.OnBeforeRequest(WebRequestHandlerOptions{Patterns: []string{"https://ads.test/*"}, Priority: 10}, func(d WebRequestDetails) WebRequestBeforeRequestResponse {
+		return WebRequestBeforeRequestResponse{Cancel: true}
+	})
+	assert.NoError(t, err)
+	_, err = r.OnBeforeRequest(WebRequestHandlerOptions{Patterns: []string{"https://*.example.com/*"}, Priority: 5}, func(d WebRequestDetails) WebRequestBeforeRequestResponse {
+		return WebRequestBeforeRequestResponse{RedirectURL: "https://high.example.com/"}
+	})
+	assert.NoError(t, err)
+	wrt.wg.Wait()
+	assert.Equal(t, []string{
+		"{\"name\":\"session.cmd.web.request.on.before.request\",\"targetID\":\"1\",\"filter\":{\"urls\":[\"https://*.example.com/*\"]}}\n",
+		"{\"name\":\"session.cmd.web.request.on.before.request\",\"targetID\":\"1\",\"filter\":{\"urls\":[\"https://ads.test/*\",\"https://*.example.com/*\"]}}\n",
+	}, wrt.w)
+
+	// Cancel wins
+	wrt.w = []string{}
+	wrt.wg.Add(1)
+	d.dispatch(Event{CallbackID: "1", Name: EventNameSessionEventWebRequestOnBeforeRequest, TargetID: s.id, WebRequest: &WebRequestDetails{URL: "https://ads.test/banner.js"}})
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"session.event.web.request.on.before.request.callback\",\"targetID\":\"1\",\"cancel\":true,\"callbackId\":\"1\"}\n"}, wrt.w)
+
+	// Highest priority redirect wins
+	wrt.w = []string{}
+	wrt.wg.Add(1)
+	d.dispatch(Event{CallbackID: "2", Name: EventNameSessionEventWebRequestOnBeforeRequest, TargetID: s.id, WebRequest: &WebRequestDetails{URL: "https://www.example.com/"}})
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"session.event.web.request.on.before.request.callback\",\"targetID\":\"1\",\"cancel\":false,\"callbackId\":\"2\",\"redirectURL\":\"https://high.example.com/\"}\n"}, wrt.w)
+
+	// No handler matches
+	wrt.w = []string{}
+	wrt.wg.Add(1)
+	d.dispatch(Event{CallbackID: "3", Name: EventNameSessionEventWebRequestOnBeforeRequest, TargetID: s.id, WebRequest: &WebRequestDetails{URL: "https://other.test/"}})
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"session.event.web.request.on.before.request.callback\",\"targetID\":\"1\",\"cancel\":false,\"callbackId\":\"3\"}\n"}, wrt.w)
+
+	// Remove
+	wrt.w = []string{}
+	wrt.wg.Add(1)
+	assert.NoError(t, r.Remove(id))
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"session.cmd.web.request.on.before.request\",\"targetID\":\"1\",\"filter\":{\"urls\":[\"https://*.example.com/*\"]}}\n"}, wrt.w)
+	assert.Error(t, r.Remove(id))
+
+	// Header handlers are chained
+	wrt.w = []string{}
+	wrt.wg.Add(1)
+	_, err = r.OnHeadersReceived(WebRequestHandlerOptions{}, func(d WebRequestDetails) WebRequestHeadersReceivedResponse {
+		d.ResponseHeaders["Content-Security-Policy"] = []string{"default-src 'self'"}
+		return WebRequestHeadersReceivedResponse{ResponseHeaders: d.ResponseHeaders}
+	})
+	assert.NoError(t, err)
+	_, err = r.OnHeadersReceived(WebRequestHandlerOptions{Patterns: []string{"<all_urls>"}}, func(d WebRequestDetails) WebRequestHeadersReceivedResponse {
+		delete(d.ResponseHeaders, "Server")
+		return WebRequestHeadersReceivedResponse{ResponseHeaders: d.ResponseHeaders}
+	})
+	assert.NoError(t, err)
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"session.cmd.web.request.on.headers.received\",\"targetID\":\"1\",\"filter\":{}}\n"}, wrt.w)
+	wrt.w = []string{}
+	wrt.wg.Add(1)
+	d.dispatch(Event{CallbackID: "4", Name: EventNameSessionEventWebRequestOnHeadersReceived, TargetID: s.id, WebRequest: &WebRequestDetails{ResponseHeaders: map[string][]string{"Server": {"test"}}, URL: "https://example.com/"}})
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"session.event.web.request.on.headers.received.callback\",\"targetID\":\"1\",\"cancel\":false,\"callbackId\":\"4\",\"responseHeaders\":{\"Content-Security-Policy\":[\"default-src 'self'\"]}}\n"}, wrt.w)
+}
+
+func TestWebRequestRouter_ConcurrentFilters(t *testing.T) {
+	// Init
+	d := newDispatcher()
+	i := newIdentifier()
+	wrt := &mockedWriter{}
+	w := newWriter(wrt, &logger{})
+	s := newSession(context.Background(), &logger{}, d, i, w)
+	r := s.WebRequest()
+
+	// Add and remove handlers concurrently
+	var wg sync.WaitGroup
+	var ps []string
+	for idx := 0; idx < 20; idx++ {
+		p := "https://" + strconv.Itoa(idx) + ".example.com/*"
+		remove := idx%2 == 0
+		if !remove {
+			ps = append(ps, p)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := r.OnCompleted(WebRequestHandlerOptions{Patterns: []string{p}}, func(d WebRequestDetails) {})
+			assert.NoError(t, err)
+			if remove {
+				assert.NoError(t, r.Remove(id))
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Electron ends up with the latest filter
+	var e Event
+	assert.NoError(t, json.Unmarshal([]byte(wrt.w[len(wrt.w)-1]), &e))
+	assert.Equal(t, EventNameSessionCmdWebRequestOnCompleted, e.Name)
+	if assert.NotNil(t, e.Filter) {
+		assert.ElementsMatch(t, ps, e.Filter.Urls)
+	}
+}
@@ -0,0 +1,63 @@
+package astilectron
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession_WebRequest(t *testing.T) {
+	// Init
+	d := newDispatcher()
+	i := newIdentifier()
+	wrt := &mockedWriter{wg: &sync.WaitGroup{}}
+	w := newWriter(wrt, &logger{})
+	s := newSession(context.Background(), &logger{}, d, i, w)
+
+	// Before send headers
+	wrt.wg.Add(1)
+	err := s.OnBeforeSendHeaders(FilterOptions{Urls: []string{"https://*/*"}}, func(d WebRequestDetails) WebRequestBeforeSendHeadersResponse {
+		d.RequestHeaders["X-Test"] = "test"
+		return WebRequestBeforeSendHeadersResponse{RequestHeaders: d.RequestHeaders}
+	})
+	assert.NoError(t, err)
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameSessionCmdWebRequestOnBeforeSendHeaders + "\",\"targetID\":\"1\",\"filter\":{\"urls\":[\"https://*/*\"]}}\n"}, wrt.w)
+	wrt.w = []string{}
+	wrt.wg.Add(1)
+	d.dispatch(Event{CallbackID: "2", Name: EventNameSessionEventWebRequestOnBeforeSendHeaders, TargetID: s.id, WebRequest: &WebRequestDetails{ID: 1, URL: "https://example.com/", RequestHeaders: map[string]string{"Accept": "*/*"}}})
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameSessionEventWebRequestOnBeforeSendHeadersCallback + "\",\"targetID\":\"1\",\"cancel\":false,\"callbackId\":\"2\",\"requestHeaders\":{\"Accept\":\"*/*\",\"X-Test\":\"test\"}}\n"}, wrt.w)
+
+	// Headers received
+	wrt.w = []string{}
+	wrt.wg.Add(1)
+	err = s.OnHeadersReceived(FilterOptions{}, func(d WebRequestDetails) WebRequestHeadersReceivedResponse {
+		return WebRequestHeadersReceivedResponse{Cancel: d.StatusCode == 404}
+	})
+	assert.NoError(t, err)
+	wrt.wg.Wait()
+	wrt.w = []string{}
+	wrt.wg.Add(1)
+	d.dispatch(Event{CallbackID: "3", Name: EventNameSessionEventWebRequestOnHeadersReceived, TargetID: s.id, WebRequest: &WebRequestDetails{ID: 2, StatusCode: 404}})
+	wrt.wg.Wait()
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameSessionEventWebRequestOnHeadersReceivedCallback + "\",\"targetID\":\"1\",\"cancel\":true,\"callbackId\":\"3\"}\n"}, wrt.w)
+
+	// Completed
+	wrt.w = []string{}
+	wrt.wg.Add(1)
+	var wg sync.WaitGroup
+	var c WebRequestDetails
+	err = s.OnCompleted(FilterOptions{}, func(d WebRequestDetails) {
+		c = d
+		wg.Done()
+	})
+	assert.NoError(t, err)
+	wrt.wg.Wait()
+	wg.Add(1)
+	d.dispatch(Event{Name: EventNameSessionEventWebRequestOnCompleted, TargetID: s.id, WebRequest: &WebRequestDetails{ID: 3, StatusCode: 200}})
+	wg.Wait()
+	assert.Equal(t, WebRequestDetails{ID: 3, StatusCode: 200}, c)
+}
@@ -2,72 +2,90 @@ package astilectron
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"image"
+	"io/fs"
 	stdUrl "net/url"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/asticode/go-astikit"
 )
 
 // Window event names
 const (
-	EventNameWebContentsEventLogin                    = "web.contents.event.login"
-	EventNameWebContentsEventLoginCallback            = "web.contents.event.login.callback"
-	EventNameWindowCmdBlur                            = "window.cmd.blur"
-	EventNameWindowCmdCenter                          = "window.cmd.center"
-	EventNameWindowCmdClose                           = "window.cmd.close"
-	EventNameWindowCmdCreate                          = "window.cmd.create"
-	EventNameWindowCmdDestroy                         = "window.cmd.destroy"
-	EventNameWindowCmdFocus                           = "window.cmd.focus"
-	EventNameWindowCmdHide                            = "window.cmd.hide"
-	EventNameWindowCmdLog                             = "window.cmd.log"
-	EventNameWindowCmdMaximize                        = "window.cmd.maximize"
-	eventNameWindowCmdMessage                         = "window.cmd.message"
-	eventNameWindowCmdMessageCallback                 = "window.cmd.message.callback"
-	EventNameWindowCmdMinimize                        = "window.cmd.minimize"
-	EventNameWindowCmdMove                            = "window.cmd.move"
-	EventNameWindowCmdResize                          = "window.cmd.resize"
-	EventNameWindowCmdSetBounds                       = "window.cmd.set.bounds"
-	EventNameWindowCmdRestore                         = "window.cmd.restore"
-	EventNameWindowCmdShow                            = "window.cmd.show"
-	EventNameWindowCmdUnmaximize                      = "window.cmd.unmaximize"
-	EventNameWindowCmdUpdateCustomOptions             = "window.cmd.update.custom.options"
-	EventNameWindowCmdWebContentsCloseDevTools        = "window.cmd.web.contents.close.dev.tools"
-	EventNameWindowCmdWebContentsOpenDevTools         = "window.cmd.web.contents.open.dev.tools"
-	EventNameWindowCmdWebContentsExecuteJavaScript    = "window.cmd.web.contents.execute.javascript"
-	EventNameWindowCmdWebContentsSetProxy             = "window.cmd.web.contents.set.proxy"
-	EventNameWindowCmdGetUrl                          = "window.cmd.get.url"
-	EventNameWindowCmdLoadURL                         = "window.cmd.load.url"
-	EventNameWindowEventBlur                          = "window.event.blur"
-	EventNameWindowEventClosed                        = "window.event.closed"
-	EventNameWindowEventDidFinishLoad                 = "window.event.did.finish.load"
-	EventNameWindowEventFocus                         = "window.event.focus"
-	EventNameWindowEventHide                          = "window.event.hide"
-	EventNameWindowEventMaximize                      = "window.event.maximize"
-	eventNameWindowEventMessage                       = "window.event.message"
-	eventNameWindowEventMessageCallback               = "window.event.message.callback"
-	EventNameWindowEventMinimize                      = "window.event.minimize"
-	EventNameWindowEventMove                          = "window.event.move"
-	EventNameWindowEventReadyToShow                   = "window.event.ready.to.show"
-	EventNameWindowEventResize                        = "window.event.resize"
-	EventNameWindowEventRestore                       = "window.event.restore"
-	EventNameWindowEventShow                          = "window.event.show"
-	EventNameWindowEventUnmaximize                    = "window.event.unmaximize"
-	EventNameWindowEventUnresponsive                  = "window.event.unresponsive"
-	EventNameWindowEventDidGetRedirectRequest         = "window.event.did.get.redirect.request"
-	EventNameWindowEventWebContentsExecutedJavaScript = "window.event.web.contents.executed.javascript"
-	EventNameWindowEventWebContentsSetProxy           = "window.event.web.contents.set.proxy"
-	EventNameWindowEventWillNavigate                  = "window.event.will.navigate"
-	EventNameWindowEventUpdatedCustomOptions          = "window.event.updated.custom.options"
-	EventNameWindowLoadedURL                          = "window.event.loaded.url"
-	EventNameWindowGetUrl                             = "window.event.get.url"
-	EventNameWindowCmdSetBrowserView                  = "window.cmd.set.browser.view"
-	EventNameWindowEventSetBrowserView                = "window.event.set.browser.view"
-	EventNameWindowCmdAddBrowserView                  = "window.cmd.add.browser.view"
-	EventNameWindowEventAddBrowserView                = "window.event.add.browser.view"
-	EventNameWindowCmdRemoveBrowserView               = "window.cmd.remove.browser.view"
-	EventNameWindowEventRemoveBrowserView             = "window.event.remove.browser.view"
+	EventNameWebContentsEventLogin                                   = "web.contents.event.login"
+	EventNameWebContentsEventLoginCallback                           = "web.contents.event.login.callback"
+	EventNameWindowCmdBlur                                           = "window.cmd.blur"
+	EventNameWindowCmdCenter                                         = "window.cmd.center"
+	EventNameWindowCmdClose                                          = "window.cmd.close"
+	EventNameWindowCmdCreate                                         = "window.cmd.create"
+	EventNameWindowCmdDestroy                                        = "window.cmd.destroy"
+	EventNameWindowCmdFocus                                          = "window.cmd.focus"
+	EventNameWindowCmdHide                                           = "window.cmd.hide"
+	EventNameWindowCmdLog                                            = "window.cmd.log"
+	EventNameWindowCmdMaximize                                       = "window.cmd.maximize"
+	eventNameWindowCmdMessage                                        = "window.cmd.message"
+	eventNameWindowCmdMessageCallback                                = "window.cmd.message.callback"
+	EventNameWindowCmdMinimize                                       = "window.cmd.minimize"
+	EventNameWindowCmdMove                                           = "window.cmd.move"
+	EventNameWindowCmdResize                                         = "window.cmd.resize"
+	EventNameWindowCmdSetBounds                                      = "window.cmd.set.bounds"
+	EventNameWindowCmdRestore                                        = "window.cmd.restore"
+	EventNameWindowCmdShow                                           = "window.cmd.show"
+	EventNameWindowCmdUnmaximize                                     = "window.cmd.unmaximize"
+	EventNameWindowCmdUpdateCustomOptions                            = "window.cmd.update.custom.options"
+	EventNameWindowCmdWebContentsCloseDevTools                       = "window.cmd.web.contents.close.dev.tools"
+	EventNameWindowCmdWebContentsCapturePage                         = "window.cmd.web.contents.capture.page"
+	EventNameWindowCmdWebContentsOpenDevTools                        = "window.cmd.web.contents.open.dev.tools"
+	EventNameWindowCmdWebContentsExecuteJavaScript                   = "window.cmd.web.contents.execute.javascript"
+	EventNameWindowCmdWebContentsExecuteJavaScriptInIsolatedWorld    = "window.cmd.web.contents.execute.javascript.in.isolated.world"
+	EventNameWindowCmdWebContentsSetProxy                            = "window.cmd.web.contents.set.proxy"
+	EventNameWindowCmdWebContentsPrintToPDF                          = "window.cmd.web.contents.print.to.pdf"
+	EventNameWindowCmdGetUrl                                         = "window.cmd.get.url"
+	EventNameWindowCmdGetState                                       = "window.cmd.get.state"
+	EventNameWindowCmdLoadURL                                        = "window.cmd.load.url"
+	EventNameWindowEventBlur                                         = "window.event.blur"
+	EventNameWindowEventClosed                                       = "window.event.closed"
+	EventNameWindowEventDidFinishLoad                                = "window.event.did.finish.load"
+	EventNameWindowEventDidFailLoad                                  = "window.event.did.fail.load"
+	EventNameWindowEventEnterFullScreen                              = "window.event.enter.full.screen"
+	EventNameWindowEventFocus                                        = "window.event.focus"
+	EventNameWindowEventHide                                         = "window.event.hide"
+	EventNameWindowEventLeaveFullScreen                              = "window.event.leave.full.screen"
+	EventNameWindowEventMaximize                                     = "window.event.maximize"
+	eventNameWindowEventMessage                                      = "window.event.message"
+	eventNameWindowEventMessageCallback                              = "window.event.message.callback"
+	EventNameWindowEventMinimize                                     = "window.event.minimize"
+	EventNameWindowEventMove                                         = "window.event.move"
+	EventNameWindowEventPageTitleUpdated                             = "window.event.page.title.updated"
+	EventNameWindowEventReadyToShow                                  = "window.event.ready.to.show"
+	EventNameWindowEventRenderProcessGone                            = "window.event.render.process.gone"
+	EventNameWindowEventResize                                       = "window.event.resize"
+	EventNameWindowEventResponsive                                   = "window.event.responsive"
+	EventNameWindowEventRestore                                      = "window.event.restore"
+	EventNameWindowEventShow                                         = "window.event.show"
+	EventNameWindowEventUnmaximize                                   = "window.event.unmaximize"
+	EventNameWindowEventUnresponsive                                 = "window.event.unresponsive"
+	EventNameWindowEventDidGetRedirectRequest                        = "window.event.did.get.redirect.request"
+	EventNameWindowEventWebContentsExecutedJavaScript                = "window.event.web.contents.executed.javascript"
+	EventNameWindowEventWebContentsCapturedPage                      = "window.event.web.contents.captured.page"
+	EventNameWindowEventWebContentsExecutedJavaScriptInIsolatedWorld = "window.event.web.contents.executed.javascript.in.isolated.world"
+	EventNameWindowEventWebContentsSetProxy                          = "window.event.web.contents.set.proxy"
+	EventNameWindowEventWebContentsPrintedToPDF                      = "window.event.web.contents.printed.to.pdf"
+	EventNameWindowEventWillNavigate                                 = "window.event.will.navigate"
+	EventNameWindowEventUpdatedCustomOptions                         = "window.event.updated.custom.options"
+	EventNameWindowLoadedURL                                         = "window.event.loaded.url"
+	EventNameWindowGetUrl                                            = "window.event.get.url"
+	EventNameWindowEventGetState                                     = "window.event.get.state"
+	EventNameWindowCmdSetBrowserView                                 = "window.cmd.set.browser.view"
+	EventNameWindowEventSetBrowserView                               = "window.event.set.browser.view"
+	EventNameWindowCmdAddBrowserView                                 = "window.cmd.add.browser.view"
+	EventNameWindowEventAddBrowserView                               = "window.event.add.browser.view"
+	EventNameWindowCmdRemoveBrowserView                              = "window.cmd.remove.browser.view"
+	EventNameWindowEventRemoveBrowserView                            = "window.event.remove.browser.view"
 )
 
 // Title bar styles
@@ -83,15 +101,38 @@ var (
 // TODO Add missing window events
 type Window struct {
 	*object
-	callbackIdentifier *identifier
-	l                  astikit.SeverityLogger
-	m                  sync.Mutex // Locks o
-	o                  *WindowOptions
-	onMessageOnce      sync.Once
-	Session            *Session
-	url                *stdUrl.URL
-	BrowserViews       map[string]*BrowserView
-	BVMutex            sync.RWMutex
+	automation              *Automation
+	callbackIdentifier      *identifier
+	children                []*Window
+	closeHooks              closeHooks
+	created                 bool
+	debugger                *Debugger
+	focused                 bool
+	l                       astikit.SeverityLogger
+	m                       sync.Mutex // Locks o, children, created, focused, maximized, minimized, navigationPolicy, navigationPolicyHooked, navigationPolicyOptions, recoveryAttempts, recoveryListening, recoveryLoadedAt, recoveryPolicy, recoveryTimer, windowOpenHandler and windowOpenListening
+	maximized               bool
+	minimized               bool
+	messageOrigins          *navigationPolicy
+	navigation              *navigation
+	navigationPolicy        *navigationPolicy
+	navigationPolicyHooked  bool
+	navigationPolicyOptions *NavigationPolicy
+	newWindow               func(url string, o *WindowOptions) (*Window, error)
+	o                       *WindowOptions
+	onMessageOnce           sync.Once
+	rawURL                  string
+	recoveryAttempts        int
+	recoveryListening       bool
+	recoveryLoadedAt        time.Time
+	recoveryPolicy          *RecoveryPolicy
+	recoveryTimer           *time.Timer
+	Session                 *Session
+	stateKeeper             *windowStateKeeper
+	url                     *stdUrl.URL
+	windowOpenHandler       func(d WindowOpenDetails) WindowOpenDecision
+	windowOpenListening     bool
+	BrowserViews            map[string]*BrowserView
+	BVMutex                 sync.RWMutex
 }
 
 // WindowOptions represents window options
@@ -136,10 +177,15 @@ type WindowOptions struct {
 	Y                      *int            `json:"y,omitempty"`
 
 	// Additional options
-	AppDetails *WindowAppDetails    `json:"appDetails,omitempty"`
-	Custom     *WindowCustomOptions `json:"custom,omitempty"`
-	Load       *WindowLoadOptions   `json:"load,omitempty"`
-	Proxy      *WindowProxyOptions  `json:"proxy,omitempty"`
+	AppDetails     *WindowAppDetails         `json:"appDetails,omitempty"`
+	Custom         *WindowCustomOptions      `json:"custom,omitempty"`
+	FS             fs.FS                     `json:"-"` // When set, the window's url is a path inside this fs.FS, e.g. an embed.FS
+	Load           *WindowLoadOptions        `json:"load,omitempty"`
+	MessageOrigins []string                  `json:"-"` // When set, messages sent from frames of other origins, including blank pages, are dropped. Origins use the syntax of NavigationPolicy.AllowedOrigins
+	Proxy          *WindowProxyOptions       `json:"proxy,omitempty"`
+	Secure         *bool                     `json:"secure,omitempty"` // When true, the window is isolated from node so that untrusted content can be loaded, and messaging goes through the astilectron preload
+	Session        *Session                  `json:"-"`                // When set, the window uses this session, e.g. one returned by Astilectron.SessionFromPartition
+	StateKeeper    *WindowStateKeeperOptions `json:"-"`
 }
 
 // WindowAppDetails represents window app details
@@ -196,15 +242,16 @@ type WebPreferences struct {
 	Images                      *bool                  `json:"images,omitempty"`
 	Javascript                  *bool                  `json:"javascript,omitempty"`
 	MinimumFontSize             *int                   `json:"minimumFontSize,omitempty"`
-	// This attribute needs to be true at all time for browserwindow
-	NodeIntegration         *bool   `json:"nodeIntegration,omitempty"`
-	NodeIntegrationInWorker *bool   `json:"nodeIntegrationInWorker,omitempty"`
-	Offscreen               *bool   `json:"offscreen,omitempty"`
-	Partition               *string `json:"partition,omitempty"`
-	Plugins                 *bool   `json:"plugins,omitempty"`
-	Preload                 *string `json:"preload,omitempty"`
-	Sandbox                 *bool   `json:"sandbox,omitempty"`
-	ScrollBounce            *bool   `json:"scrollBounce,omitempty"`
+	// This attribute needs to be true for messaging to work, unless WindowOptions.Secure is true
+	NodeIntegration          *bool   `json:"nodeIntegration,omitempty"`
+	NodeIntegrationInWorker  *bool   `json:"nodeIntegrationInWorker,omitempty"`
+	Offscreen                *bool   `json:"offscreen,omitempty"`
+	PaintWhenInitiallyHidden *bool   `json:"paintWhenInitiallyHidden,omitempty"`
+	Partition                *string `json:"partition,omitempty"`
+	Plugins                  *bool   `json:"plugins,omitempty"`
+	Preload                  *string `json:"preload,omitempty"`
+	Sandbox                  *bool   `json:"sandbox,omitempty"`
+	ScrollBounce             *bool   `json:"scrollBounce,omitempty"`
 	//id for the session being referenced
 	Session               *string  `json:"session,omitempty"`
 	TextAreasAreResizable *bool    `json:"textAreasAreResizable,omitempty"`
@@ -216,17 +263,40 @@ type WebPreferences struct {
 }
 
 // newWindow creates a new window
-func newWindow(ctx context.Context, l astikit.SeverityLogger, o Options, p Paths, url string, wo *WindowOptions, d *dispatcher, i *identifier, wrt *writer) (w *Window, err error) {
+func newWindow(ctx context.Context, l astikit.SeverityLogger, o Options, p Paths, url string, wo *WindowOptions, dp *displayPool, d *dispatcher, i *identifier, wrt *writer) (w *Window, err error) {
 	// Init
 	w = &Window{
 		callbackIdentifier: newIdentifier(),
 		l:                  l,
 		o:                  wo,
 		object:             newObject(ctx, d, i, wrt, i.new()),
+		rawURL:             url,
 		BrowserViews:       make(map[string]*BrowserView),
 		BVMutex:            sync.RWMutex{},
 	}
-	w.Session = newSession(w.ctx, d, i, wrt)
+	w.debugger = newDebugger(w.object)
+	w.navigation = newNavigation(w.object, l, windowNavigationEventNames, w.isCreated)
+	w.automation = newAutomation(w.debugger, w.Evaluate)
+	if wo.Session != nil {
+		w.Session = wo.Session
+		useSession(wo)
+	} else {
+		w.Session = newSession(w.ctx, l, d, i, wrt)
+	}
+
+	// Message origins
+	if wo.MessageOrigins != nil {
+		if w.messageOrigins, err = newNavigationPolicy(NavigationPolicy{AllowedOrigins: wo.MessageOrigins}); err != nil {
+			err = fmt.Errorf("parsing message origins failed: %w", err)
+			return
+		}
+	}
+
+	// Secure
+	if err = secureWindowOptions(wo, p); err != nil {
+		err = fmt.Errorf("securing window options failed: %w", err)
+		return
+	}
 
 	// Check app details
 	if wo.Icon == nil && p.AppIconDefaultSrc() != "" {
@@ -236,25 +306,57 @@ func newWindow(ctx context.Context, l astikit.SeverityLogger, o Options, p Paths
 		wo.Title = astikit.StrPtr(o.AppName)
 	}
 
+	// Restore state
+	if wo.StateKeeper != nil {
+		if w.stateKeeper, err = newWindowStateKeeper(l, p, dp, *wo.StateKeeper); err != nil {
+			err = fmt.Errorf("creating window state keeper failed: %w", err)
+			return
+		}
+		if err = w.stateKeeper.restore(wo); err != nil {
+			err = fmt.Errorf("restoring window state failed: %w", err)
+			return
+		}
+	}
+
 	// Make sure the window's context is cancelled once the closed event is received
 	w.On(EventNameWindowEventClosed, func(e Event) (deleteListener bool) {
 		w.cancel()
+		if w.stateKeeper != nil {
+			if err := w.stateKeeper.flush(); err != nil {
+				w.l.Error(fmt.Errorf("saving window state failed: %w", err))
+			}
+		}
 		return true
 	})
 
-	// Show
-	w.On(EventNameWindowEventHide, func(e Event) (deleteListener bool) {
-		w.m.Lock()
-		defer w.m.Unlock()
-		w.o.Show = astikit.BoolPtr(false)
-		return
-	})
-	w.On(EventNameWindowEventShow, func(e Event) (deleteListener bool) {
-		w.m.Lock()
-		defer w.m.Unlock()
-		w.o.Show = astikit.BoolPtr(true)
-		return
-	})
+	// Keep the window state up to date, applying events in the order they were sent by Electron
+	for _, n := range []string{
+		EventNameWindowEventBlur,
+		EventNameWindowEventEnterFullScreen,
+		EventNameWindowEventFocus,
+		EventNameWindowEventHide,
+		EventNameWindowEventLeaveFullScreen,
+		EventNameWindowEventMaximize,
+		EventNameWindowEventMinimize,
+		EventNameWindowEventMove,
+		EventNameWindowEventPageTitleUpdated,
+		EventNameWindowEventResize,
+		EventNameWindowEventRestore,
+		EventNameWindowEventShow,
+		EventNameWindowEventUnmaximize,
+	} {
+		w.d.setOrderedHandler(w.id, n, func(e Event) {
+			w.updateState(e)
+			if w.stateKeeper != nil {
+				w.stateKeeper.update(w.State())
+			}
+		})
+	}
+
+	// FS
+	if wo.FS != nil {
+		url = fsURL(w.id, url)
+	}
 
 	// Basic parse
 	if w.url, err = stdUrl.Parse(url); err != nil {
@@ -276,11 +378,131 @@ func newWindow(ctx context.Context, l astikit.SeverityLogger, o Options, p Paths
 	return
 }
 
+// secureWindowOptions enables the web preferences of secure windows, leaving the web preferences of other windows
+// untouched
+// Web preferences explicitly set to insecure values are rejected rather than overwritten.
+func secureWindowOptions(wo *WindowOptions, p Paths) (err error) {
+	// Not secure
+	if wo.Secure == nil || !*wo.Secure {
+		return
+	}
+
+	// Create web preferences
+	if wo.WebPreferences == nil {
+		wo.WebPreferences = &WebPreferences{}
+	}
+	wp := wo.WebPreferences
+
+	// Loop through secure values
+	for _, v := range []struct {
+		name   string
+		ptr    **bool
+		secure bool
+	}{
+		{name: "contextIsolation", ptr: &wp.ContextIsolation, secure: true},
+		{name: "enableRemoteModule", ptr: &wp.EnableRemoteModule},
+		{name: "nodeIntegration", ptr: &wp.NodeIntegration},
+		{name: "nodeIntegrationInWorker", ptr: &wp.NodeIntegrationInWorker},
+		{name: "sandbox", ptr: &wp.Sandbox, secure: true},
+		{name: "webviewTag", ptr: &wp.WebviewTag},
+	} {
+		if *v.ptr == nil {
+			*v.ptr = astikit.BoolPtr(v.secure)
+		} else if **v.ptr != v.secure {
+			err = fmt.Errorf("%s must be %v in secure windows", v.name, v.secure)
+			return
+		}
+	}
+
+	// Preload
+	if wp.Preload == nil {
+		wp.Preload = astikit.StrPtr(p.AstilectronPreload())
+	} else if *wp.Preload != p.AstilectronPreload() {
+		err = errors.New("preload can't be set in secure windows since the astilectron preload is used")
+		return
+	}
+	return
+}
+
+// updateState updates the window state based on an event
+func (w *Window) updateState(e Event) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	// Event name
+	switch e.Name {
+	case EventNameWindowEventBlur:
+		w.focused = false
+	case EventNameWindowEventEnterFullScreen:
+		w.o.Fullscreen = astikit.BoolPtr(true)
+	case EventNameWindowEventFocus:
+		w.focused = true
+	case EventNameWindowEventHide:
+		w.o.Show = astikit.BoolPtr(false)
+	case EventNameWindowEventLeaveFullScreen:
+		w.o.Fullscreen = astikit.BoolPtr(false)
+	case EventNameWindowEventMaximize:
+		w.maximized = true
+	case EventNameWindowEventMinimize:
+		w.minimized = true
+	case EventNameWindowEventRestore:
+		w.minimized = false
+	case EventNameWindowEventShow:
+		w.o.Show = astikit.BoolPtr(true)
+	case EventNameWindowEventUnmaximize:
+		w.maximized = false
+	}
+
+	// No state
+	if e.WindowState == nil {
+		return
+	}
+
+	// State
+	if e.WindowState.Bounds != nil {
+		if e.WindowState.Bounds.Height != nil {
+			w.o.Height = astikit.IntPtr(*e.WindowState.Bounds.Height)
+		}
+		if e.WindowState.Bounds.Width != nil {
+			w.o.Width = astikit.IntPtr(*e.WindowState.Bounds.Width)
+		}
+		if e.WindowState.Bounds.X != nil {
+			w.o.X = astikit.IntPtr(*e.WindowState.Bounds.X)
+		}
+		if e.WindowState.Bounds.Y != nil {
+			w.o.Y = astikit.IntPtr(*e.WindowState.Bounds.Y)
+		}
+	}
+	if e.WindowState.IsFocused != nil {
+		w.focused = *e.WindowState.IsFocused
+	}
+	if e.WindowState.IsFullScreen != nil {
+		w.o.Fullscreen = astikit.BoolPtr(*e.WindowState.IsFullScreen)
+	}
+	if e.WindowState.IsMaximized != nil {
+		w.maximized = *e.WindowState.IsMaximized
+	}
+	if e.WindowState.IsMinimized != nil {
+		w.minimized = *e.WindowState.IsMinimized
+	}
+	if e.WindowState.IsVisible != nil {
+		w.o.Show = astikit.BoolPtr(*e.WindowState.IsVisible)
+	}
+	if e.WindowState.Title != nil {
+		w.o.Title = astikit.StrPtr(*e.WindowState.Title)
+	}
+}
+
 // NewMenu creates a new window menu
 func (w *Window) NewMenu(i []*MenuItemOptions) *Menu {
 	return newMenu(w.ctx, w.id, i, w.d, w.i, w.w)
 }
 
+// Automation returns the object capable of driving the window's web contents
+func (w *Window) Automation() *Automation {
+	return w.automation
+}
+
 // Blur blurs the window
 func (w *Window) Blur() (err error) {
 	if err = w.ctx.Err(); err != nil {
@@ -290,6 +512,19 @@ func (w *Window) Blur() (err error) {
 	return
 }
 
+// CapturePage captures a snapshot of the window's page as PNG bytes
+// A nil rect means the whole visible page is captured. Hidden windows are only painted if
+// WebPreferences.PaintWhenInitiallyHidden is not false and WebPreferences.BackgroundThrottling is false, or with
+// WebPreferences.Offscreen.
+func (w *Window) CapturePage(ctx context.Context, rect *RectangleOptions) ([]byte, error) {
+	return capturePage(ctx, w.object, EventNameWindowCmdWebContentsCapturePage, EventNameWindowEventWebContentsCapturedPage, rect)
+}
+
+// CapturePageImage is the same as CapturePage except the snapshot is decoded into an image
+func (w *Window) CapturePageImage(ctx context.Context, rect *RectangleOptions) (image.Image, error) {
+	return capturePageImage(ctx, w.object, EventNameWindowCmdWebContentsCapturePage, EventNameWindowEventWebContentsCapturedPage, rect)
+}
+
 // Center centers the window
 func (w *Window) Center() (err error) {
 	if err = w.ctx.Err(); err != nil {
@@ -316,6 +551,13 @@ func (w *Window) CloseDevTools() (err error) {
 	return w.w.write(Event{Name: EventNameWindowCmdWebContentsCloseDevTools, TargetID: w.id})
 }
 
+// isCreated returns whether Create has been called
+func (w *Window) isCreated() bool {
+	w.m.Lock()
+	defer w.m.Unlock()
+	return w.created
+}
+
 // Create creates the window
 // We wait for EventNameWindowEventDidFinishLoad since we need the web content to be fully loaded before being able to
 // send messages to it
@@ -323,7 +565,31 @@ func (w *Window) Create() (err error) {
 	if err = w.ctx.Err(); err != nil {
 		return
 	}
-	_, err = synchronousEvent(w.ctx, w, w.w, Event{Name: EventNameWindowCmdCreate, SessionID: w.Session.id, TargetID: w.id, URL: w.url.String(), WindowOptions: w.o}, EventNameWindowEventDidFinishLoad)
+
+	// Get the handlers that have been set before the window was created, so that they're set before its url is loaded
+	var hs []string
+	w.m.Lock()
+	w.created = true
+	if w.navigation.hooked() {
+		hs = append(hs, EventNameWindowCmdWebContentsSetWillNavigateHandler)
+	}
+	if w.windowOpenHandler != nil || w.navigationPolicy != nil {
+		hs = append(hs, EventNameWindowCmdSetWindowOpenHandler)
+	}
+	if !w.closeHooks.empty() {
+		hs = append(hs, EventNameWindowCmdSetCloseRequestHandler)
+	}
+	w.m.Unlock()
+
+	// Create
+	if _, err = synchronousEvent(w.ctx, w, w.w, Event{Handlers: hs, Name: EventNameWindowCmdCreate, SessionID: w.Session.id, TargetID: w.id, URL: w.url.String(), WindowOptions: w.o}, EventNameWindowEventDidFinishLoad); err != nil {
+		return
+	}
+
+	// Restore maximized state
+	if w.stateKeeper != nil && w.stateKeeper.isMaximized() {
+		err = w.Maximize()
+	}
 	return
 }
 
@@ -370,6 +636,69 @@ func (w *Window) RemoveBrowserView(browserView *BrowserView) (err error) {
 	return
 }
 
+// Debugger returns the Chrome DevTools Protocol debugger of the window's web contents
+func (w *Window) Debugger() *Debugger {
+	return w.debugger
+}
+
+// GoBack makes the window go back to the previous page
+func (w *Window) GoBack(ctx context.Context) error {
+	return w.navigation.goBack(ctx)
+}
+
+// GoForward makes the window go forward to the next page
+func (w *Window) GoForward(ctx context.Context) error {
+	return w.navigation.goForward(ctx)
+}
+
+// GoToIndex makes the window navigate to an entry of its navigation history
+func (w *Window) GoToIndex(ctx context.Context, index int) error {
+	return w.navigation.goToIndex(ctx, index)
+}
+
+// CanGoBack returns whether the window can go back to a previous page
+func (w *Window) CanGoBack(ctx context.Context) (bool, error) {
+	h, err := w.navigation.history(ctx)
+	return h.CanGoBack(), err
+}
+
+// CanGoForward returns whether the window can go forward to a next page
+func (w *Window) CanGoForward(ctx context.Context) (bool, error) {
+	h, err := w.navigation.history(ctx)
+	return h.CanGoForward(), err
+}
+
+// NavigationHistory returns the navigation history of the window
+func (w *Window) NavigationHistory(ctx context.Context) (NavigationHistory, error) {
+	return w.navigation.history(ctx)
+}
+
+// Reload reloads the window's page
+func (w *Window) Reload(ctx context.Context) error {
+	return w.navigation.reload(ctx)
+}
+
+// ReloadIgnoringCache reloads the window's page without using the cache
+func (w *Window) ReloadIgnoringCache(ctx context.Context) error {
+	return w.navigation.reloadIgnoringCache(ctx)
+}
+
+// Stop stops any pending navigation of the window
+func (w *Window) Stop(ctx context.Context) error {
+	return w.navigation.stop(ctx)
+}
+
+// OnWillNavigate adds a hook deciding whether the window can navigate to a url when the navigation is initiated by the
+// page (e.g. links, window.location), which is not the case of LoadURL, GoBack, GoForward, GoToIndex or Reload
+// The navigation is prevented as soon as one hook doesn't allow it. Electron can't wait for the hooks' decision: it
+// prevents the navigation and, if it's allowed, replays it by loading its url, which drops POST bodies and the
+// referrer. Once navigations have happened, Electron emits EventNameWindowEventWebContentsDidNavigate and, for in-page
+// navigations such as anchors or the history API, EventNameWindowEventWebContentsDidNavigateInPage, with the new url
+// in Event.URL.
+func (w *Window) OnWillNavigate(fn func(url string) (allow bool)) {
+	w.navigation.onWillNavigate(fn)
+}
+
 // Destroy destroys the window
 func (w *Window) Destroy() (err error) {
 	if err = w.ctx.Err(); err != nil {
@@ -379,7 +708,21 @@ func (w *Window) Destroy() (err error) {
 	return
 }
 
+// Evaluate evaluates a JS expression in the main world of the window and decodes its JSON serialized result into out,
+// unless out is nil
+// If the expression returns a promise, its resolved value is used. Exceptions are returned as *JavaScriptError.
+func (w *Window) Evaluate(ctx context.Context, code string, out interface{}) error {
+	return evaluateJavaScript(ctx, w.object, EventNameWindowCmdWebContentsExecuteJavaScript, EventNameWindowEventWebContentsExecutedJavaScript, nil, code, out)
+}
+
+// EvaluateInIsolatedWorld is the same as Evaluate except the expression is evaluated in an isolated world, which shares
+// the DOM of the page but not its JS globals
+func (w *Window) EvaluateInIsolatedWorld(ctx context.Context, worldID int, code string, out interface{}) error {
+	return evaluateJavaScript(ctx, w.object, EventNameWindowCmdWebContentsExecuteJavaScriptInIsolatedWorld, EventNameWindowEventWebContentsExecutedJavaScriptInIsolatedWorld, &worldID, code, out)
+}
+
 // ExecuteJavaScript executes some js
+// Use Evaluate to get its result
 func (w *Window) ExecuteJavaScript(code string) (err error) {
 	if err = w.ctx.Err(); err != nil {
 		return
@@ -406,6 +749,74 @@ func (w *Window) Hide() (err error) {
 	return
 }
 
+// Bounds returns the last known bounds of the window
+func (w *Window) Bounds() (r Rectangle) {
+	if w.ctx.Err() != nil {
+		return
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	return w.bounds()
+}
+
+// bounds returns the last known bounds of the window
+// It assumes the mutex is locked
+func (w *Window) bounds() (r Rectangle) {
+	if w.o.Height != nil {
+		r.Height = *w.o.Height
+	}
+	if w.o.Width != nil {
+		r.Width = *w.o.Width
+	}
+	if w.o.X != nil {
+		r.X = *w.o.X
+	}
+	if w.o.Y != nil {
+		r.Y = *w.o.Y
+	}
+	return
+}
+
+// IsFocused returns whether the window is focused
+func (w *Window) IsFocused() bool {
+	if w.ctx.Err() != nil {
+		return false
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	return w.focused
+}
+
+// IsFullScreen returns whether the window is in full screen mode
+func (w *Window) IsFullScreen() bool {
+	if w.ctx.Err() != nil {
+		return false
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	return w.o.Fullscreen != nil && *w.o.Fullscreen
+}
+
+// IsMaximized returns whether the window is maximized
+func (w *Window) IsMaximized() bool {
+	if w.ctx.Err() != nil {
+		return false
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	return w.maximized
+}
+
+// IsMinimized returns whether the window is minimized
+func (w *Window) IsMinimized() bool {
+	if w.ctx.Err() != nil {
+		return false
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	return w.minimized
+}
+
 // IsShown returns whether the window is shown
 func (w *Window) IsShown() bool {
 	if w.ctx.Err() != nil {
@@ -416,6 +827,25 @@ func (w *Window) IsShown() bool {
 	return w.o.Show != nil && *w.o.Show
 }
 
+// Title returns the last known title of the window
+func (w *Window) Title() string {
+	if w.ctx.Err() != nil {
+		return ""
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	return w.title()
+}
+
+// title returns the last known title of the window
+// It assumes the mutex is locked
+func (w *Window) title() string {
+	if w.o.Title == nil {
+		return ""
+	}
+	return *w.o.Title
+}
+
 // Log logs a message in the JS console of the window
 func (w *Window) Log(message string) (err error) {
 	if err = w.ctx.Err(); err != nil {
@@ -487,25 +917,48 @@ func (w *Window) OnLogin(fn func(i Event) (username, password string, err error)
 type ListenerMessage func(m *EventMessage) (v interface{})
 
 // OnMessage adds a specific listener executed when receiving a message from the JS
+// The frame the message has been sent from is available through EventMessage.Sender. If WindowOptions.MessageOrigins
+// is set, messages sent from other origins, including blank pages and frames without origin, are dropped without the
+// listener being executed, and their callback, if any, is executed without message.
 // This method can be called only once
 func (w *Window) OnMessage(l ListenerMessage) {
 	w.onMessageOnce.Do(func() {
 		w.On(eventNameWindowEventMessage, func(i Event) (deleteListener bool) {
-			v := l(i.Message)
-			if len(i.CallbackID) > 0 {
-				o := Event{CallbackID: i.CallbackID, Name: eventNameWindowCmdMessageCallback, TargetID: w.id}
-				if v != nil {
-					o.Message = newEventMessage(v)
-				}
-				if err := w.w.write(o); err != nil {
-					w.l.Error(fmt.Errorf("writing callback message failed: %w", err))
+			// Check origin
+			if w.messageOrigins != nil && (i.Sender == nil || !w.messageOrigins.allowsOrigin(i.Sender.Origin)) {
+				var origin string
+				if i.Sender != nil {
+					origin = i.Sender.Origin
 				}
+				w.l.Warnf("Dropping message sent from origin %q", origin)
+				w.sendMessageCallback(i.CallbackID, nil)
+				return
 			}
+
+			// Execute listener
+			if i.Message != nil {
+				i.Message.sender = i.Sender
+			}
+			w.sendMessageCallback(i.CallbackID, l(i.Message))
 			return
 		})
 	})
 }
 
+// sendMessageCallback sends the reply to a message back to the JS, if it's waiting for one
+func (w *Window) sendMessageCallback(callbackID string, v interface{}) {
+	if len(callbackID) == 0 {
+		return
+	}
+	o := Event{CallbackID: callbackID, Name: eventNameWindowCmdMessageCallback, TargetID: w.id}
+	if v != nil {
+		o.Message = newEventMessage(v)
+	}
+	if err := w.w.write(o); err != nil {
+		w.l.Error(fmt.Errorf("writing callback message failed: %w", err))
+	}
+}
+
 // OpenDevTools opens the dev tools
 func (w *Window) OpenDevTools() (err error) {
 	if err = w.ctx.Err(); err != nil {
@@ -514,6 +967,11 @@ func (w *Window) OpenDevTools() (err error) {
 	return w.w.write(Event{Name: EventNameWindowCmdWebContentsOpenDevTools, TargetID: w.id})
 }
 
+// PrintToPDF prints the window's page as PDF bytes, which works with hidden windows as well
+func (w *Window) PrintToPDF(ctx context.Context, o PrintToPDFOptions) ([]byte, error) {
+	return printToPDF(ctx, w.object, EventNameWindowCmdWebContentsPrintToPDF, EventNameWindowEventWebContentsPrintedToPDF, o)
+}
+
 // Resize resizes the window
 func (w *Window) Resize(width, height int) (err error) {
 	if err = w.ctx.Err(); err != nil {
@@ -632,3 +1090,67 @@ func (w *Window) GetUrl() (e Event, err error) {
 	e, err = synchronousEvent(w.ctx, w, w.w, Event{Name: EventNameWindowCmdGetUrl, TargetID: w.id}, EventNameWindowGetUrl)
 	return
 }
+
+// WindowState represents the state of a window
+type WindowState struct {
+	Bounds       Rectangle
+	IsFocused    bool
+	IsFullScreen bool
+	IsMaximized  bool
+	IsMinimized  bool
+	IsShown      bool
+	Title        string
+}
+
+// GetState fetches the state of the window from Electron and updates the window accordingly
+func (w *Window) GetState() (s WindowState, err error) {
+	if err = w.ctx.Err(); err != nil {
+		return
+	}
+	var e Event
+	if e, err = synchronousEvent(w.ctx, w, w.w, Event{Name: EventNameWindowCmdGetState, TargetID: w.id}, EventNameWindowEventGetState); err != nil {
+		return
+	}
+	w.updateState(e)
+	s = w.State()
+	return
+}
+
+// GetBounds fetches the bounds of the window from Electron
+func (w *Window) GetBounds() (r Rectangle, err error) {
+	var s WindowState
+	if s, err = w.GetState(); err != nil {
+		return
+	}
+	r = s.Bounds
+	return
+}
+
+// GetTitle fetches the title of the window from Electron
+func (w *Window) GetTitle() (t string, err error) {
+	var s WindowState
+	if s, err = w.GetState(); err != nil {
+		return
+	}
+	t = s.Title
+	return
+}
+
+// State returns the last known state of the window
+// The state is read at once so that it's consistent even if events are being received in the meantime.
+func (w *Window) State() (s WindowState) {
+	if w.ctx.Err() != nil {
+		return
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	return WindowState{
+		Bounds:       w.bounds(),
+		IsFocused:    w.focused,
+		IsFullScreen: w.o.Fullscreen != nil && *w.o.Fullscreen,
+		IsMaximized:  w.maximized,
+		IsMinimized:  w.minimized,
+		IsShown:      w.o.Show != nil && *w.o.Show,
+		Title:        w.title(),
+	}
+}
@@ -0,0 +1,225 @@
+package astilectron
+
+import (
+	"fmt"
+)
+
+// Window open event names
+const (
+	EventNameWindowCmdSetWindowOpenHandler   = "window.cmd.set.window.open.handler"
+	EventNameWindowCmdUnsetWindowOpenHandler = "window.cmd.unset.window.open.handler"
+	EventNameWindowEventWindowOpen           = "window.event.window.open"
+	EventNameWindowEventWindowOpenCallback   = "window.event.window.open.callback"
+)
+
+// Window open actions
+const (
+	WindowOpenActionAllow    = "allow"
+	WindowOpenActionDeny     = "deny"
+	WindowOpenActionExternal = "external" // The url is opened in the system browser
+)
+
+// WindowOpenDetails represents the details of a window opened by a page, either with window.open or with a link
+// targeting a new window
+// https://github.com/electron/electron/blob/v12.0.0/docs/api/web-contents.md#contentssetwindowopenhandlerhandler
+type WindowOpenDetails struct {
+	Disposition string `json:"disposition,omitempty"` // E.g. "default", "foreground-tab", "background-tab" or "new-window"
+	Features    string `json:"features,omitempty"`
+	FrameName   string `json:"frameName,omitempty"`
+	Referrer    string `json:"referrer,omitempty"`
+	URL         string `json:"url"`
+}
+
+// WindowOpenDecision represents how a window opened by a page is handled
+// When the action is allow, the child window is opened by GO: either Window is set and must not have been created
+// yet, or a new window is created with WindowOptions. Unless specified otherwise, the child window uses the session of
+// its parent.
+type WindowOpenDecision struct {
+	Action        string
+	Window        *Window
+	WindowOptions *WindowOptions
+}
+
+// SetWindowOpenHandler sets the handler deciding how windows opened by the window's page are handled
+// Once a handler is set, Electron doesn't open windows by itself anymore: allowed child windows are GO windows that
+// can be retrieved with ChildWindows. A nil handler restores Electron's default behavior, unless a navigation policy
+// is set.
+func (w *Window) SetWindowOpenHandler(fn func(d WindowOpenDetails) WindowOpenDecision) (err error) {
+	// Check context
+	if err = w.ctx.Err(); err != nil {
+		return
+	}
+
+	// Store handler
+	w.m.Lock()
+	w.windowOpenHandler = fn
+	w.m.Unlock()
+
+	// Update listener
+	return w.updateWindowOpenListener()
+}
+
+// updateWindowOpenListener lets Electron know whether it should forward window open events, which is the case when
+// either a window open handler or a navigation policy is set
+func (w *Window) updateWindowOpenListener() (err error) {
+	// Check whether events are needed
+	w.m.Lock()
+	needed := w.windowOpenHandler != nil || w.navigationPolicy != nil
+	listening := w.windowOpenListening
+	if needed {
+		w.windowOpenListening = true
+	}
+	created := w.created
+	w.m.Unlock()
+
+	// Listen
+	if needed && !listening {
+		w.On(EventNameWindowEventWindowOpen, func(e Event) (deleteListener bool) {
+			w.handleWindowOpen(e)
+			return
+		})
+	}
+
+	// Window has not been created yet, in which case the handler is set when it is
+	if !created {
+		return
+	}
+
+	// Unset
+	if !needed {
+		return w.w.write(Event{Name: EventNameWindowCmdUnsetWindowOpenHandler, TargetID: w.id})
+	}
+	return w.w.write(Event{Name: EventNameWindowCmdSetWindowOpenHandler, TargetID: w.id})
+}
+
+// ChildWindows returns the child windows opened by the window's page that haven't been closed yet
+func (w *Window) ChildWindows() []*Window {
+	w.m.Lock()
+	defer w.m.Unlock()
+	return append([]*Window{}, w.children...)
+}
+
+// handleWindowOpen lets the window open handler decide, opens the child window if needed and sends the decision back
+// to Electron
+func (w *Window) handleWindowOpen(e Event) {
+	// Get handler and policy
+	w.m.Lock()
+	fn := w.windowOpenHandler
+	p := w.navigationPolicy
+	po := w.navigationPolicyOptions
+	w.m.Unlock()
+
+	// Decide, denying the window if both the handler and the policy have been unset in the meantime
+	d := WindowOpenDecision{Action: WindowOpenActionDeny}
+	var od WindowOpenDetails
+	if e.WindowOpen != nil {
+		od = *e.WindowOpen
+	}
+	if p != nil && !p.check(NavigationPolicyViolationPopup, od.URL) {
+		d.Action = WindowOpenActionDeny
+	} else if fn != nil {
+		d = fn(od)
+	} else if p != nil {
+		d.Action = WindowOpenActionAllow
+	}
+
+	// Open child window
+	var c *Window
+	if d.Action == WindowOpenActionAllow {
+		var err error
+		if c, err = w.openChildWindow(od.URL, d, po); err != nil {
+			w.l.Error(fmt.Errorf("opening child window of %s failed: %w", od.URL, err))
+			d.Action = WindowOpenActionDeny
+		}
+	} else if d.Action != WindowOpenActionExternal {
+		d.Action = WindowOpenActionDeny
+	}
+
+	// Send decision back
+	o := Event{CallbackID: e.CallbackID, Name: EventNameWindowEventWindowOpenCallback, TargetID: w.id, WindowOpenAction: d.Action}
+	if c != nil {
+		o.WindowID = c.id
+	}
+	if err := w.w.write(o); err != nil {
+		w.l.Error(fmt.Errorf("writing %s event failed: %w", EventNameWindowEventWindowOpenCallback, err))
+	}
+}
+
+// openChildWindow creates the child window of an allowed decision and tracks it until it's closed
+// The child window is not tracked anymore if it can't be created.
+func (w *Window) openChildWindow(url string, d WindowOpenDecision, po *NavigationPolicy) (c *Window, err error) {
+	// Get child window
+	if c, err = w.childWindow(url, d); err != nil {
+		err = fmt.Errorf("getting child window failed: %w", err)
+		return
+	}
+
+	// Track child window
+	w.m.Lock()
+	w.children = append(w.children, c)
+	w.m.Unlock()
+	c.On(EventNameWindowEventClosed, func(e Event) (deleteListener bool) {
+		w.removeChild(c)
+		return true
+	})
+
+	// Share navigation policy before the child window loads its url
+	if po != nil {
+		if err = c.SetNavigationPolicy(po); err != nil {
+			w.removeChild(c)
+			c = nil
+			err = fmt.Errorf("setting navigation policy failed: %w", err)
+			return
+		}
+	}
+
+	// Create child window
+	if err = c.Create(); err != nil {
+		w.removeChild(c)
+		c = nil
+		err = fmt.Errorf("creating child window failed: %w", err)
+		return
+	}
+	return
+}
+
+// removeChild stops tracking a child window
+func (w *Window) removeChild(c *Window) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	for idx, cw := range w.children {
+		if cw == c {
+			w.children = append(w.children[:idx], w.children[idx+1:]...)
+			return
+		}
+	}
+}
+
+// childWindow returns the child window of an allowed decision
+func (w *Window) childWindow(url string, d WindowOpenDecision) (c *Window, err error) {
+	// Window has been provided
+	if d.Window != nil {
+		if d.Window.isCreated() {
+			err = fmt.Errorf("window %s has already been created", d.Window.id)
+			return
+		}
+		c = d.Window
+		return
+	}
+
+	// No way to create windows
+	if w.newWindow == nil {
+		err = fmt.Errorf("window %s can't create windows", w.id)
+		return
+	}
+
+	// Create window
+	o := &WindowOptions{}
+	if d.WindowOptions != nil {
+		*o = *d.WindowOptions
+	}
+	if o.Session == nil {
+		o.Session = w.Session
+	}
+	return w.newWindow(url, o)
+}
@@ -0,0 +1,111 @@
+package astilectron
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/asticode/go-astikit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindow_SetWindowOpenHandler(t *testing.T) {
+	// Init
+	a, err := New(nil, Options{})
+	assert.NoError(t, err)
+	defer a.Close()
+	wrt := &mockedWriter{}
+	a.writer = newWriter(wrt, &logger{})
+	w, err := a.NewWindow("http://test.com", &WindowOptions{})
+	assert.NoError(t, err)
+	w.created = true
+	cancelled, err := a.NewWindow("http://cancelled.com", &WindowOptions{})
+	assert.NoError(t, err)
+	cancelled.cancel()
+	created, err := a.NewWindow("http://created.com", &WindowOptions{})
+	assert.NoError(t, err)
+	created.created = true
+
+	// Set handler
+	testObjectAction(t, func() error {
+		return w.SetWindowOpenHandler(func(d WindowOpenDetails) WindowOpenDecision {
+			switch d.URL {
+			case "http://external.com":
+				return WindowOpenDecision{Action: WindowOpenActionExternal}
+			case "http://popup.com":
+				return WindowOpenDecision{Action: WindowOpenActionAllow, WindowOptions: &WindowOptions{Width: astikit.IntPtr(100)}}
+			case "http://cancelled.com":
+				return WindowOpenDecision{Action: WindowOpenActionAllow, Window: cancelled}
+			case "http://created.com":
+				return WindowOpenDecision{Action: WindowOpenActionAllow, Window: created}
+			}
+			return WindowOpenDecision{}
+		})
+	}, w.object, wrt, "{\"name\":\""+EventNameWindowCmdSetWindowOpenHandler+"\",\"targetID\":\""+w.id+"\"}\n", "")
+
+	// Handle events
+	var m sync.Mutex
+	var es []Event
+	wrt.fn = func() {
+		var e Event
+		json.Unmarshal([]byte(wrt.w[len(wrt.w)-1]), &e)
+		m.Lock()
+		es = append(es, e)
+		m.Unlock()
+		if e.Name == EventNameWindowCmdCreate {
+			w.d.dispatch(Event{CallbackID: e.CallbackID, Name: EventNameWindowEventDidFinishLoad, TargetID: e.TargetID})
+		}
+	}
+	events := func(n int) []Event {
+		testEventually(t, func() bool {
+			m.Lock()
+			defer m.Unlock()
+			return len(es) == n
+		})
+		m.Lock()
+		defer m.Unlock()
+		r := es
+		es = []Event{}
+		return r
+	}
+
+	// Deny
+	w.d.dispatch(Event{CallbackID: "1", Name: EventNameWindowEventWindowOpen, TargetID: w.id, WindowOpen: &WindowOpenDetails{URL: "http://deny.com"}})
+	assert.Equal(t, []Event{{CallbackID: "1", Name: EventNameWindowEventWindowOpenCallback, TargetID: w.id, WindowOpenAction: WindowOpenActionDeny}}, events(1))
+
+	// External
+	w.d.dispatch(Event{CallbackID: "2", Name: EventNameWindowEventWindowOpen, TargetID: w.id, WindowOpen: &WindowOpenDetails{URL: "http://external.com"}})
+	assert.Equal(t, []Event{{CallbackID: "2", Name: EventNameWindowEventWindowOpenCallback, TargetID: w.id, WindowOpenAction: WindowOpenActionExternal}}, events(1))
+
+	// Allow
+	w.d.dispatch(Event{CallbackID: "3", Name: EventNameWindowEventWindowOpen, TargetID: w.id, WindowOpen: &WindowOpenDetails{FrameName: "popup", URL: "http://popup.com"}})
+	r := events(2)
+	cs := w.ChildWindows()
+	assert.Len(t, cs, 1)
+	c := cs[0]
+	assert.Equal(t, EventNameWindowCmdCreate, r[0].Name)
+	assert.Equal(t, c.id, r[0].TargetID)
+	assert.Equal(t, w.Session.id, r[0].SessionID)
+	assert.Equal(t, "http://popup.com", r[0].URL)
+	assert.Equal(t, 100, *r[0].WindowOptions.Width)
+	assert.Equal(t, Event{CallbackID: "3", Name: EventNameWindowEventWindowOpenCallback, TargetID: w.id, WindowID: c.id, WindowOpenAction: WindowOpenActionAllow}, r[1])
+	assert.Equal(t, w.Session, c.Session)
+
+	// Child window can't be created
+	w.d.dispatch(Event{CallbackID: "4", Name: EventNameWindowEventWindowOpen, TargetID: w.id, WindowOpen: &WindowOpenDetails{URL: "http://cancelled.com"}})
+	assert.Equal(t, []Event{{CallbackID: "4", Name: EventNameWindowEventWindowOpenCallback, TargetID: w.id, WindowOpenAction: WindowOpenActionDeny}}, events(1))
+	assert.Equal(t, []*Window{c}, w.ChildWindows())
+
+	// Child window has already been created
+	w.d.dispatch(Event{CallbackID: "5", Name: EventNameWindowEventWindowOpen, TargetID: w.id, WindowOpen: &WindowOpenDetails{URL: "http://created.com"}})
+	assert.Equal(t, []Event{{CallbackID: "5", Name: EventNameWindowEventWindowOpenCallback, TargetID: w.id, WindowOpenAction: WindowOpenActionDeny}}, events(1))
+	assert.Equal(t, []*Window{c}, w.ChildWindows())
+
+	// Child window is closed
+	w.d.dispatch(Event{Name: EventNameWindowEventClosed, TargetID: c.id})
+	testEventually(t, func() bool { return len(w.ChildWindows()) == 0 })
+	wrt.fn = nil
+
+	// Unset handler
+	testObjectAction(t, func() error { return w.SetWindowOpenHandler(nil) }, w.object, wrt, "{\"name\":\""+EventNameWindowCmdUnsetWindowOpenHandler+"\",\"targetID\":\""+w.id+"\"}\n", "")
+}
@@ -0,0 +1,248 @@
+package astilectron
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astikit"
+)
+
+// Window state keeper default values
+const (
+	windowStateKeeperDirectoryName = "window-states"
+	windowStateKeeperSaveDelay     = 500 * time.Millisecond
+)
+
+// WindowStateKeeperOptions represents window state keeper options
+// When provided, the window's bounds, maximized and full screen states are saved in a file named after the Name
+// attribute and located in the data directory, and are restored the next time a window with the same name is created
+type WindowStateKeeperOptions struct {
+	Name string
+}
+
+// windowStateKeeper represents an object capable of saving and restoring a window state
+type windowStateKeeper struct {
+	dp   *displayPool
+	l    astikit.SeverityLogger
+	m    sync.Mutex // Locks s and t
+	path string
+	s    windowStateKeeperState
+	t    *time.Timer
+}
+
+// windowStateKeeperState represents the state saved by the window state keeper
+// Bounds are the bounds of the window when it was neither maximized, minimized nor in full screen mode
+type windowStateKeeperState struct {
+	DisplayID    *int64 `json:"displayId,omitempty"`
+	Height       int    `json:"height"`
+	IsFullScreen bool   `json:"isFullScreen,omitempty"`
+	IsMaximized  bool   `json:"isMaximized,omitempty"`
+	Width        int    `json:"width"`
+	X            int    `json:"x"`
+	Y            int    `json:"y"`
+}
+
+// newWindowStateKeeper creates a new window state keeper
+func newWindowStateKeeper(l astikit.SeverityLogger, p Paths, dp *displayPool, o WindowStateKeeperOptions) (k *windowStateKeeper, err error) {
+	// Validate name
+	if o.Name == "" || o.Name != filepath.Base(o.Name) || o.Name == "." || o.Name == ".." {
+		err = fmt.Errorf("window state keeper name %q is invalid", o.Name)
+		return
+	}
+
+	// Create keeper
+	k = &windowStateKeeper{
+		dp:   dp,
+		l:    l,
+		path: filepath.Join(p.DataDirectory(), windowStateKeeperDirectoryName, o.Name+".json"),
+	}
+	return
+}
+
+// restore restores the saved state, if any, in the window options
+func (k *windowStateKeeper) restore(wo *WindowOptions) (err error) {
+	k.m.Lock()
+	defer k.m.Unlock()
+
+	// Read file
+	var b []byte
+	if b, err = ioutil.ReadFile(k.path); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+			k.seed(wo)
+			return
+		}
+		err = fmt.Errorf("reading %s failed: %w", k.path, err)
+		return
+	}
+
+	// Unmarshal
+	// A corrupt file, e.g. after a write has been interrupted, must not prevent the window from being created
+	if err = json.Unmarshal(b, &k.s); err != nil {
+		k.l.Error(fmt.Errorf("unmarshaling %s failed: %w", k.path, err))
+		err = nil
+		k.s = windowStateKeeperState{}
+		k.seed(wo)
+		return
+	}
+
+	// No bounds
+	if k.s.Height <= 0 || k.s.Width <= 0 {
+		k.seed(wo)
+		return
+	}
+
+	// Make sure the window is visible on a connected display
+	r := Rectangle{Position: Position{X: k.s.X, Y: k.s.Y}, Size: Size{Height: k.s.Height, Width: k.s.Width}}
+	var d *Display
+	if k.s.DisplayID != nil {
+		d = k.dp.get(*k.s.DisplayID)
+	}
+	if d == nil {
+		d = k.dp.primary()
+	}
+	if d != nil && d.o.WorkArea != nil {
+		r = clampRectangle(r, d.WorkArea())
+	}
+
+	// Update state and options
+	k.s.Height, k.s.Width, k.s.X, k.s.Y = r.Height, r.Width, r.X, r.Y
+	wo.Center = nil
+	wo.Height = astikit.IntPtr(r.Height)
+	wo.Width = astikit.IntPtr(r.Width)
+	wo.X = astikit.IntPtr(r.X)
+	wo.Y = astikit.IntPtr(r.Y)
+	if k.s.IsFullScreen {
+		wo.Fullscreen = astikit.BoolPtr(true)
+	}
+	return
+}
+
+// seed initializes the state bounds with the window options bounds
+func (k *windowStateKeeper) seed(wo *WindowOptions) {
+	if wo.Height != nil {
+		k.s.Height = *wo.Height
+	}
+	if wo.Width != nil {
+		k.s.Width = *wo.Width
+	}
+	if wo.X != nil {
+		k.s.X = *wo.X
+	}
+	if wo.Y != nil {
+		k.s.Y = *wo.Y
+	}
+}
+
+// isMaximized returns whether the saved state is maximized
+func (k *windowStateKeeper) isMaximized() bool {
+	k.m.Lock()
+	defer k.m.Unlock()
+	return k.s.IsMaximized
+}
+
+// update updates the state based on the window state and schedules a save
+func (k *windowStateKeeper) update(s WindowState) {
+	k.m.Lock()
+	defer k.m.Unlock()
+
+	// Minimized windows keep their previous state
+	if s.IsMinimized {
+		return
+	}
+
+	// Update state
+	k.s.IsFullScreen = s.IsFullScreen
+	k.s.IsMaximized = s.IsMaximized
+	if !s.IsFullScreen && !s.IsMaximized && s.Bounds.Height > 0 && s.Bounds.Width > 0 {
+		k.s.Height = s.Bounds.Height
+		k.s.Width = s.Bounds.Width
+		k.s.X = s.Bounds.X
+		k.s.Y = s.Bounds.Y
+	}
+	k.s.DisplayID = nil
+	if d := k.dp.matching(s.Bounds); d != nil {
+		k.s.DisplayID = astikit.Int64Ptr(d.ID())
+	}
+
+	// Schedule save since some events such as move or resize may be triggered a lot
+	if k.t != nil {
+		k.t.Stop()
+	}
+	k.t = time.AfterFunc(windowStateKeeperSaveDelay, func() {
+		if err := k.save(); err != nil {
+			k.l.Error(fmt.Errorf("saving window state failed: %w", err))
+		}
+	})
+}
+
+// flush saves the state right away
+func (k *windowStateKeeper) flush() error {
+	k.m.Lock()
+	if k.t != nil {
+		k.t.Stop()
+		k.t = nil
+	}
+	k.m.Unlock()
+	return k.save()
+}
+
+// save saves the state
+func (k *windowStateKeeper) save() (err error) {
+	k.m.Lock()
+	defer k.m.Unlock()
+
+	// Marshal
+	var b []byte
+	if b, err = json.Marshal(k.s); err != nil {
+		err = fmt.Errorf("marshaling failed: %w", err)
+		return
+	}
+
+	// Make sure the directory exists
+	if err = os.MkdirAll(filepath.Dir(k.path), 0755); err != nil {
+		err = fmt.Errorf("mkdirall %s failed: %w", filepath.Dir(k.path), err)
+		return
+	}
+
+	// Write in a temporary file first so that the state file is never partially written
+	tmp := k.path + ".tmp"
+	if err = ioutil.WriteFile(tmp, b, 0644); err != nil {
+		err = fmt.Errorf("writing %s failed: %w", tmp, err)
+		return
+	}
+	if err = os.Rename(tmp, k.path); err != nil {
+		err = fmt.Errorf("renaming %s into %s failed: %w", tmp, k.path, err)
+		return
+	}
+	return
+}
+
+// clampRectangle makes sure a rectangle fits inside an area
+func clampRectangle(r, area Rectangle) Rectangle {
+	// Size
+	if r.Width > area.Width {
+		r.Width = area.Width
+	}
+	if r.Height > area.Height {
+		r.Height = area.Height
+	}
+
+	// Position
+	if r.X < area.X {
+		r.X = area.X
+	} else if r.X+r.Width > area.X+area.Width {
+		r.X = area.X + area.Width - r.Width
+	}
+	if r.Y < area.Y {
+		r.Y = area.Y
+	} else if r.Y+r.Height > area.Y+area.Height {
+		r.Y = area.Y + area.Height - r.Height
+	}
+	return r
+}
@@ -0,0 +1,92 @@
+package astilectron
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asticode/go-astikit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClampRectangle(t *testing.T) {
+	a := Rectangle{Position: Position{X: 100, Y: 100}, Size: Size{Height: 100, Width: 200}}
+	assert.Equal(t, Rectangle{Position: Position{X: 110, Y: 120}, Size: Size{Height: 10, Width: 20}}, clampRectangle(Rectangle{Position: Position{X: 110, Y: 120}, Size: Size{Height: 10, Width: 20}}, a))
+	assert.Equal(t, Rectangle{Position: Position{X: 100, Y: 100}, Size: Size{Height: 10, Width: 20}}, clampRectangle(Rectangle{Position: Position{X: 10, Y: 20}, Size: Size{Height: 10, Width: 20}}, a))
+	assert.Equal(t, Rectangle{Position: Position{X: 280, Y: 190}, Size: Size{Height: 10, Width: 20}}, clampRectangle(Rectangle{Position: Position{X: 1000, Y: 2000}, Size: Size{Height: 10, Width: 20}}, a))
+	assert.Equal(t, Rectangle{Position: Position{X: 100, Y: 100}, Size: Size{Height: 100, Width: 200}}, clampRectangle(Rectangle{Position: Position{X: 150, Y: 150}, Size: Size{Height: 1000, Width: 2000}}, a))
+}
+
+func TestWindowStateKeeper(t *testing.T) {
+	// Init
+	dir, err := ioutil.TempDir("", "astilectron")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	p, err := newPaths("linux", "amd64", Options{DataDirectoryPath: dir})
+	assert.NoError(t, err)
+	dp := newDisplayPool()
+	dp.update(&EventDisplays{
+		All: []*DisplayOptions{
+			{
+				Bounds:   &RectangleOptions{PositionOptions: PositionOptions{X: astikit.IntPtr(0), Y: astikit.IntPtr(0)}, SizeOptions: SizeOptions{Height: astikit.IntPtr(1000), Width: astikit.IntPtr(1000)}},
+				ID:       astikit.Int64Ptr(1),
+				WorkArea: &RectangleOptions{PositionOptions: PositionOptions{X: astikit.IntPtr(0), Y: astikit.IntPtr(0)}, SizeOptions: SizeOptions{Height: astikit.IntPtr(900), Width: astikit.IntPtr(1000)}},
+			},
+			{
+				Bounds:   &RectangleOptions{PositionOptions: PositionOptions{X: astikit.IntPtr(1000), Y: astikit.IntPtr(0)}, SizeOptions: SizeOptions{Height: astikit.IntPtr(1000), Width: astikit.IntPtr(1000)}},
+				ID:       astikit.Int64Ptr(2),
+				WorkArea: &RectangleOptions{PositionOptions: PositionOptions{X: astikit.IntPtr(1000), Y: astikit.IntPtr(0)}, SizeOptions: SizeOptions{Height: astikit.IntPtr(900), Width: astikit.IntPtr(1000)}},
+			},
+		},
+		Primary: &DisplayOptions{ID: astikit.Int64Ptr(1)},
+	})
+
+	// Invalid name
+	_, err = newWindowStateKeeper(&logger{}, *p, dp, WindowStateKeeperOptions{Name: "../name"})
+	assert.Error(t, err)
+
+	// No saved state
+	k, err := newWindowStateKeeper(&logger{}, *p, dp, WindowStateKeeperOptions{Name: "name"})
+	assert.NoError(t, err)
+	wo := &WindowOptions{Center: astikit.BoolPtr(true), Height: astikit.IntPtr(100), Width: astikit.IntPtr(200)}
+	err = k.restore(wo)
+	assert.NoError(t, err)
+	assert.Equal(t, &WindowOptions{Center: astikit.BoolPtr(true), Height: astikit.IntPtr(100), Width: astikit.IntPtr(200)}, wo)
+
+	// Save
+	k.update(WindowState{Bounds: Rectangle{Position: Position{X: 1100, Y: 100}, Size: Size{Height: 300, Width: 400}}})
+	k.update(WindowState{Bounds: Rectangle{Position: Position{X: 1000}, Size: Size{Height: 900, Width: 1000}}, IsMaximized: true})
+	k.update(WindowState{Bounds: Rectangle{Position: Position{X: 1100, Y: 100}, Size: Size{Height: 300, Width: 400}}, IsMaximized: true, IsMinimized: true})
+	err = k.flush()
+	assert.NoError(t, err)
+	b, err := ioutil.ReadFile(filepath.Join(dir, "window-states", "name.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"displayId\":2,\"height\":300,\"isMaximized\":true,\"width\":400,\"x\":1100,\"y\":100}", string(b))
+
+	// Restore
+	k, err = newWindowStateKeeper(&logger{}, *p, dp, WindowStateKeeperOptions{Name: "name"})
+	assert.NoError(t, err)
+	wo = &WindowOptions{Center: astikit.BoolPtr(true)}
+	err = k.restore(wo)
+	assert.NoError(t, err)
+	assert.Equal(t, &WindowOptions{Height: astikit.IntPtr(300), Width: astikit.IntPtr(400), X: astikit.IntPtr(1100), Y: astikit.IntPtr(100)}, wo)
+	assert.True(t, k.isMaximized())
+
+	// Restore on a disconnected display
+	err = ioutil.WriteFile(filepath.Join(dir, "window-states", "name.json"), []byte("{\"displayId\":3,\"height\":300,\"isFullScreen\":true,\"width\":400,\"x\":3000,\"y\":100}"), 0644)
+	assert.NoError(t, err)
+	wo = &WindowOptions{}
+	err = k.restore(wo)
+	assert.NoError(t, err)
+	assert.Equal(t, &WindowOptions{Fullscreen: astikit.BoolPtr(true), Height: astikit.IntPtr(300), Width: astikit.IntPtr(400), X: astikit.IntPtr(600), Y: astikit.IntPtr(100)}, wo)
+
+	// Restore a corrupt file
+	err = ioutil.WriteFile(filepath.Join(dir, "window-states", "name.json"), []byte("{\"displayId\":3,\"hei"), 0644)
+	assert.NoError(t, err)
+	wo = &WindowOptions{Height: astikit.IntPtr(100), Width: astikit.IntPtr(200)}
+	err = k.restore(wo)
+	assert.NoError(t, err)
+	assert.Equal(t, &WindowOptions{Height: astikit.IntPtr(100), Width: astikit.IntPtr(200)}, wo)
+	assert.False(t, k.isMaximized())
+}
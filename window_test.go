@@ -1,8 +1,11 @@
 package astilectron
 
 import (
+	"encoding/json"
+	"strconv"
 	"sync"
 	"testing"
+	"testing/fstest"
 
 	"github.com/asticode/go-astikit"
 	"github.com/stretchr/testify/assert"
@@ -107,6 +110,42 @@ func TestWindow_OnMessage(t *testing.T) {
 	assert.Equal(t, []string{"{\"name\":\"window.cmd.message.callback\",\"targetID\":\"1\",\"callbackId\":\"1\",\"message\":\"test\"}\n"}, wrt.w)
 }
 
+func TestWindow_OnMessageSender(t *testing.T) {
+	a, err := New(nil, Options{})
+	assert.NoError(t, err)
+	defer a.Close()
+	wrt := &mockedWriter{wg: &sync.WaitGroup{}}
+	a.writer = newWriter(wrt, &logger{})
+	_, err = a.NewWindow("http://test.com", &WindowOptions{MessageOrigins: []string{"invalid"}})
+	assert.Error(t, err)
+	w, err := a.NewWindow("http://test.com", &WindowOptions{MessageOrigins: []string{"http://test.com"}})
+	assert.NoError(t, err)
+	var ss []*MessageSender
+	w.OnMessage(func(m *EventMessage) interface{} {
+		ss = append(ss, m.Sender())
+		return nil
+	})
+	var e Event
+	assert.NoError(t, json.Unmarshal([]byte("{\"name\":\"window.event.message\",\"targetID\":\""+w.id+"\",\"callbackId\":\"1\",\"message\":\"foo\",\"sender\":{\"frameId\":1,\"frameUrl\":\"http://test.com/index.html\",\"isMainFrame\":true,\"origin\":\"http://test.com\"}}"), &e))
+	wrt.wg.Add(1)
+	a.dispatcher.dispatch(e)
+	wrt.wg.Wait()
+	assert.Equal(t, []*MessageSender{{FrameID: 1, FrameURL: "http://test.com/index.html", IsMainFrame: true, Origin: "http://test.com"}}, ss)
+
+	// Messages from other origins are dropped and their callback is executed without message
+	for idx, s := range []*MessageSender{{BrowserViewID: "2", FrameID: 2, Origin: "http://evil.com"}, nil, {FrameID: 4, FrameURL: "about:blank", Origin: "about:blank"}, {FrameID: 5, FrameURL: "about:srcdoc", Origin: "null"}} {
+		wrt.w = []string{}
+		wrt.wg.Add(1)
+		a.dispatcher.dispatch(Event{CallbackID: strconv.Itoa(idx + 2), Message: newEventMessage([]byte("\"foo\"")), Name: eventNameWindowEventMessage, Sender: s, TargetID: w.id})
+		wrt.wg.Wait()
+		assert.Equal(t, []string{"{\"name\":\"" + eventNameWindowCmdMessageCallback + "\",\"targetID\":\"" + w.id + "\",\"callbackId\":\"" + strconv.Itoa(idx+2) + "\"}\n"}, wrt.w)
+	}
+	wrt.wg.Add(1)
+	a.dispatcher.dispatch(Event{CallbackID: "6", Message: newEventMessage([]byte("\"foo\"")), Name: eventNameWindowEventMessage, Sender: &MessageSender{BrowserViewID: "2", FrameID: 3, Origin: "http://test.com"}, TargetID: w.id})
+	wrt.wg.Wait()
+	assert.Equal(t, []*MessageSender{{FrameID: 1, FrameURL: "http://test.com/index.html", IsMainFrame: true, Origin: "http://test.com"}, {BrowserViewID: "2", FrameID: 3, Origin: "http://test.com"}}, ss)
+}
+
 func TestWindow_SendMessage(t *testing.T) {
 	a, err := New(nil, Options{})
 	assert.NoError(t, err)
@@ -139,3 +178,135 @@ func TestWindow_NewMenu(t *testing.T) {
 	m := w.NewMenu([]*MenuItemOptions{})
 	assert.Equal(t, w.id, m.rootID)
 }
+
+func TestWindow_State(t *testing.T) {
+	a, err := New(nil, Options{})
+	assert.NoError(t, err)
+	defer a.Close()
+	wrt := &mockedWriter{}
+	a.writer = newWriter(wrt, &logger{})
+	w, err := a.NewWindow("http://test.com", &WindowOptions{Height: astikit.IntPtr(1), Title: astikit.StrPtr("title")})
+	assert.NoError(t, err)
+	assert.Equal(t, WindowState{Bounds: Rectangle{Size: Size{Height: 1}}, Title: "title"}, w.State())
+
+	// Events without state
+	w.updateState(Event{Name: EventNameWindowEventFocus})
+	w.updateState(Event{Name: EventNameWindowEventMaximize})
+	w.updateState(Event{Name: EventNameWindowEventMinimize})
+	w.updateState(Event{Name: EventNameWindowEventEnterFullScreen})
+	w.updateState(Event{Name: EventNameWindowEventShow})
+	assert.Equal(t, WindowState{Bounds: Rectangle{Size: Size{Height: 1}}, IsFocused: true, IsFullScreen: true, IsMaximized: true, IsMinimized: true, IsShown: true, Title: "title"}, w.State())
+	w.updateState(Event{Name: EventNameWindowEventBlur})
+	w.updateState(Event{Name: EventNameWindowEventUnmaximize})
+	w.updateState(Event{Name: EventNameWindowEventRestore})
+	w.updateState(Event{Name: EventNameWindowEventLeaveFullScreen})
+	w.updateState(Event{Name: EventNameWindowEventHide})
+	assert.Equal(t, WindowState{Bounds: Rectangle{Size: Size{Height: 1}}, Title: "title"}, w.State())
+
+	// Events with state
+	w.updateState(Event{Name: EventNameWindowEventMove, WindowState: &EventWindowState{
+		Bounds:      &RectangleOptions{PositionOptions: PositionOptions{X: astikit.IntPtr(1), Y: astikit.IntPtr(2)}, SizeOptions: SizeOptions{Height: astikit.IntPtr(3), Width: astikit.IntPtr(4)}},
+		IsFocused:   astikit.BoolPtr(true),
+		IsMaximized: astikit.BoolPtr(true),
+		IsVisible:   astikit.BoolPtr(true),
+		Title:       astikit.StrPtr("new title"),
+	}})
+	assert.Equal(t, WindowState{Bounds: Rectangle{Position: Position{X: 1, Y: 2}, Size: Size{Height: 3, Width: 4}}, IsFocused: true, IsMaximized: true, IsShown: true, Title: "new title"}, w.State())
+
+	// Dispatched events are applied in order
+	for _, n := range []string{EventNameWindowEventMaximize, EventNameWindowEventUnmaximize, EventNameWindowEventBlur, EventNameWindowEventFocus} {
+		a.dispatcher.dispatch(Event{Name: n, TargetID: w.id})
+	}
+	assert.False(t, w.IsMaximized())
+	assert.True(t, w.IsFocused())
+
+	// Get state
+	testObjectAction(t, func() error {
+		_, err := w.GetState()
+		return err
+	}, w.object, wrt, "{\"name\":\""+EventNameWindowCmdGetState+"\",\"targetID\":\""+w.id+"\"}\n", EventNameWindowEventGetState)
+
+	// Closed window
+	w.cancel()
+	assert.Equal(t, Rectangle{}, w.Bounds())
+	assert.Equal(t, "", w.Title())
+	assert.Equal(t, WindowState{}, w.State())
+}
+
+func TestWindow_FS(t *testing.T) {
+	a, err := New(nil, Options{})
+	assert.NoError(t, err)
+	defer a.Close()
+	wrt := &mockedWriter{}
+	wrt.fn = func() { a.dispatcher.dispatch(Event{Name: EventNameProtocolEventHandled, TargetID: targetIDApp}) }
+	a.writer = newWriter(wrt, &logger{})
+	w, err := a.NewWindow("index.html", &WindowOptions{FS: fstest.MapFS{"index.html": &fstest.MapFile{Data: []byte("index")}}})
+	assert.NoError(t, err)
+	assert.Equal(t, FSScheme+"://window-"+w.id+"/index.html", w.url.String())
+	assert.Equal(t, []string{"{\"name\":\"" + EventNameProtocolCmdHandle + "\",\"targetID\":\"app\",\"scheme\":\"" + FSScheme + "\"}\n"}, wrt.w)
+	_, err = a.NewWindow("", &WindowOptions{FS: fstest.MapFS{}})
+	assert.NoError(t, err)
+	assert.Len(t, wrt.w, 1)
+
+	// Windows wait for the protocol being handled without holding the mutex
+	a, err = New(nil, Options{})
+	assert.NoError(t, err)
+	defer a.Close()
+	wrt = &mockedWriter{}
+	handling, handled := make(chan bool), make(chan bool)
+	wrt.fn = func() {
+		handling <- true
+		<-handled
+		a.dispatcher.dispatch(Event{Name: EventNameProtocolEventHandled, TargetID: targetIDApp})
+	}
+	a.writer = newWriter(wrt, &logger{})
+	var wg sync.WaitGroup
+	for idx := 0; idx < 2; idx++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := a.NewWindow("", &WindowOptions{FS: fstest.MapFS{}})
+			assert.NoError(t, err)
+		}()
+	}
+	<-handling
+	a.m.Lock()
+	a.m.Unlock()
+	close(handled)
+	wg.Wait()
+	assert.Len(t, wrt.w, 1)
+}
+
+func TestWindow_Secure(t *testing.T) {
+	// Init
+	a, err := New(nil, Options{})
+	assert.NoError(t, err)
+
+	// Not secure
+	w, err := a.NewWindow("http://test.com", &WindowOptions{})
+	assert.NoError(t, err)
+	assert.Nil(t, w.o.WebPreferences)
+
+	// Secure
+	w, err = a.NewWindow("http://test.com", &WindowOptions{Secure: astikit.BoolPtr(true), WebPreferences: &WebPreferences{DevTools: astikit.BoolPtr(true), Sandbox: astikit.BoolPtr(true)}})
+	assert.NoError(t, err)
+	assert.Equal(t, &WebPreferences{
+		ContextIsolation:        astikit.BoolPtr(true),
+		DevTools:                astikit.BoolPtr(true),
+		EnableRemoteModule:      astikit.BoolPtr(false),
+		NodeIntegration:         astikit.BoolPtr(false),
+		NodeIntegrationInWorker: astikit.BoolPtr(false),
+		Preload:                 astikit.StrPtr(a.Paths().AstilectronPreload()),
+		Sandbox:                 astikit.BoolPtr(true),
+		WebviewTag:              astikit.BoolPtr(false),
+	}, w.o.WebPreferences)
+	b, err := a.NewBrowserView("http://test.com", &WindowOptions{Secure: astikit.BoolPtr(true)}, nil)
+	assert.NoError(t, err)
+	assert.False(t, *b.o.WebPreferences.NodeIntegration)
+
+	// Insecure web preferences
+	_, err = a.NewWindow("http://test.com", &WindowOptions{Secure: astikit.BoolPtr(true), WebPreferences: &WebPreferences{NodeIntegration: astikit.BoolPtr(true)}})
+	assert.EqualError(t, err, "securing window options failed: nodeIntegration must be false in secure windows")
+	_, err = a.NewWindow("http://test.com", &WindowOptions{Secure: astikit.BoolPtr(true), WebPreferences: &WebPreferences{Preload: astikit.StrPtr("/path/to/preload.js")}})
+	assert.EqualError(t, err, "securing window options failed: preload can't be set in secure windows since the astilectron preload is used")
+}